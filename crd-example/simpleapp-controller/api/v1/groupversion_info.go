@@ -1,3 +1,6 @@
+// Package v1 contains API Schema definitions for the example.com v1 API group
+// +kubebuilder:object:generate=true
+// +groupName=example.com
 package v1
 
 import (
@@ -14,4 +17,4 @@ var (
 
 	// AddToScheme adds the types in this group-version to the given scheme.
 	AddToScheme = SchemeBuilder.AddToScheme
-) 
\ No newline at end of file
+)
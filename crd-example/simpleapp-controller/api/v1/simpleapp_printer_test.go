@@ -0,0 +1,91 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+func TestSimpleAppDiscovery(t *testing.T) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources, err := dc.ServerResourcesForGroupVersion(GroupVersion.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name != "simpleapps" {
+			continue
+		}
+		if !slices.Equal(resource.ShortNames, []string{"sapp"}) {
+			t.Errorf("short names = %v, want [sapp]", resource.ShortNames)
+		}
+		if !slices.Contains(resource.Categories, "all") {
+			t.Errorf("categories = %v, want SimpleApps listed by `kubectl get all`", resource.Categories)
+		}
+		return
+	}
+	t.Fatalf("simpleapps not found in %v", resources.APIResources)
+}
+
+func TestSimpleAppPrinterColumns(t *testing.T) {
+	ctx := context.Background()
+	obj := newSimpleApp("printer-columns", map[string]interface{}{"appName": "my-app", "replicas": 3})
+	if err := k8sClient.Create(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := k8sClient.Delete(ctx, obj); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// Ask the API server for the table that kubectl get prints
+	httpClient, err := rest.HTTPClientFor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(cfg.Host, "/")+"/apis/"+GroupVersion.String()+"/namespaces/default/simpleapps/printer-columns", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json;as=Table;v=v1;g=meta.k8s.io")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s", req.URL, resp.Status)
+	}
+	table := &metav1.Table{}
+	if err := json.NewDecoder(resp.Body).Decode(table); err != nil {
+		t.Fatal(err)
+	}
+
+	var columns []string
+	for _, column := range table.ColumnDefinitions {
+		columns = append(columns, column.Name)
+	}
+	wantColumns := []string{"Name", "App", "Desired", "Ready", "Phase", "Age"}
+	if !slices.Equal(columns, wantColumns) {
+		t.Fatalf("columns = %v, want %v", columns, wantColumns)
+	}
+	if len(table.Rows) != 1 {
+		t.Fatalf("rows = %d, want 1", len(table.Rows))
+	}
+	cells := table.Rows[0].Cells
+	if cells[0] != "printer-columns" || cells[1] != "my-app" || cells[2] != float64(3) {
+		t.Errorf("cells = %v, want printer-columns, my-app and 3 desired replicas", cells)
+	}
+}
@@ -2,59 +2,82 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // SimpleAppSpec defines the desired state of SimpleApp
 type SimpleAppSpec struct {
-	AppName  string `json:"appName"`
-	Replicas int32  `json:"replicas"`
+	// AppName is the name of the Deployment and of the container running the
+	// app. It must be a DNS-1123 label and cannot be changed once set.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="appName is immutable"
+	AppName string `json:"appName"`
+
+	// Replicas is the number of pods of the app
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	Replicas int32 `json:"replicas"`
 }
 
+// SimpleAppPhase is a summary of the state of a SimpleApp
+type SimpleAppPhase string
+
+const (
+	// SimpleAppPending means that no replica of the app is ready yet
+	SimpleAppPending SimpleAppPhase = "Pending"
+	// SimpleAppProgressing means that some replicas are ready, or the Deployment is rolling out
+	SimpleAppProgressing SimpleAppPhase = "Progressing"
+	// SimpleAppRunning means that all the replicas of the app are ready
+	SimpleAppRunning SimpleAppPhase = "Running"
+	// SimpleAppFailed means that the Deployment of the app could not be reconciled
+	SimpleAppFailed SimpleAppPhase = "Failed"
+)
+
+// ConditionReady is the condition type reporting whether all the replicas of a SimpleApp are ready
+const ConditionReady = "Ready"
+
 // SimpleAppStatus defines the observed state of SimpleApp
 type SimpleAppStatus struct {
-	// Add status fields if needed
+	// Phase is a summary of the state of the app
+	// +optional
+	Phase SimpleAppPhase `json:"phase,omitempty"`
+
+	// ReadyReplicas is the number of ready pods of the Deployment
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// ObservedGeneration is the generation of the SimpleApp last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions are the latest observations of the state of the app
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=sapp,categories=all
+// +kubebuilder:printcolumn:name="App",type=string,JSONPath=`.spec.appName`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // SimpleApp is the Schema for the simpleapps API
 type SimpleApp struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec   SimpleAppSpec   `json:"spec,omitempty"`
+	Spec   SimpleAppSpec   `json:"spec"`
 	Status SimpleAppStatus `json:"status,omitempty"`
 }
 
-// DeepCopyInto copies all properties of this object into another object of the same type
-func (in *SimpleApp) DeepCopyInto(out *SimpleApp) {
-	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
-}
-
-// DeepCopy creates a deep copy of SimpleApp
-func (in *SimpleApp) DeepCopy() *SimpleApp {
-	if in == nil {
-		return nil
-	}
-	out := new(SimpleApp)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyObject creates a deep copy of an object
-func (in *SimpleApp) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // +kubebuilder:object:root=true
 
 // SimpleAppList contains a list of SimpleApp
@@ -64,38 +87,6 @@ type SimpleAppList struct {
 	Items           []SimpleApp `json:"items"`
 }
 
-// DeepCopyInto copies all properties of this object into another object of the same type
-func (in *SimpleAppList) DeepCopyInto(out *SimpleAppList) {
-	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]SimpleApp, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-}
-
-// DeepCopy creates a deep copy of SimpleAppList
-func (in *SimpleAppList) DeepCopy() *SimpleAppList {
-	if in == nil {
-		return nil
-	}
-	out := new(SimpleAppList)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyObject creates a deep copy of an object
-func (in *SimpleAppList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 func init() {
 	SchemeBuilder.Register(&SimpleApp{}, &SimpleAppList{})
-} 
\ No newline at end of file
+}
@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// cfg and k8sClient talk to the API server started by TestMain, which serves
+// the generated SimpleApp CRD
+var (
+	cfg       *rest.Config
+	k8sClient client.Client
+)
+
+func TestMain(m *testing.M) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "..", "simpleapp-crd.yaml")},
+		ErrorIfCRDPathMissing: true,
+		// Used when KUBEBUILDER_ASSETS is not set, see the test target of the Makefile
+		BinaryAssetsDirectory: filepath.Join("..", "..", "bin", "k8s",
+			fmt.Sprintf("1.29.0-%s-%s", goruntime.GOOS, goruntime.GOARCH)),
+	}
+	var err error
+	cfg, err = testEnv.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "starting envtest: %v\n", err)
+		os.Exit(1)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	if err := testEnv.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "stopping envtest: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// newSimpleApp returns a SimpleApp named name with the given spec, as an
+// unstructured object so that fields can be left out entirely
+func newSimpleApp(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": GroupVersion.String(),
+		"kind":       "SimpleApp",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+		},
+	}}
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+	return obj
+}
+
+func TestSimpleAppValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "valid",
+			spec: map[string]interface{}{"appName": "my-app", "replicas": 3},
+		},
+		{
+			name: "longest name",
+			spec: map[string]interface{}{"appName": strings.Repeat("a", 63), "replicas": 1},
+		},
+		{
+			name:    "missing spec",
+			wantErr: "spec: Required value",
+		},
+		{
+			name:    "missing appName",
+			spec:    map[string]interface{}{"replicas": 1},
+			wantErr: "spec.appName: Required value",
+		},
+		{
+			name:    "missing replicas",
+			spec:    map[string]interface{}{"appName": "my-app"},
+			wantErr: "spec.replicas: Required value",
+		},
+		{
+			name:    "empty appName",
+			spec:    map[string]interface{}{"appName": "", "replicas": 1},
+			wantErr: "spec.appName",
+		},
+		{
+			name:    "uppercase appName",
+			spec:    map[string]interface{}{"appName": "My-App", "replicas": 1},
+			wantErr: "spec.appName: Invalid value",
+		},
+		{
+			name:    "appName with a dot",
+			spec:    map[string]interface{}{"appName": "my.app", "replicas": 1},
+			wantErr: "spec.appName: Invalid value",
+		},
+		{
+			name:    "appName ending with a dash",
+			spec:    map[string]interface{}{"appName": "my-app-", "replicas": 1},
+			wantErr: "spec.appName: Invalid value",
+		},
+		{
+			name:    "appName too long",
+			spec:    map[string]interface{}{"appName": strings.Repeat("a", 64), "replicas": 1},
+			wantErr: "spec.appName: Too long",
+		},
+		{
+			name:    "zero replicas",
+			spec:    map[string]interface{}{"appName": "my-app", "replicas": 0},
+			wantErr: "spec.replicas: Invalid value",
+		},
+		{
+			name:    "negative replicas",
+			spec:    map[string]interface{}{"appName": "my-app", "replicas": -1},
+			wantErr: "spec.replicas: Invalid value",
+		},
+		{
+			name:    "too many replicas",
+			spec:    map[string]interface{}{"appName": "my-app", "replicas": 11},
+			wantErr: "spec.replicas: Invalid value",
+		},
+	}
+
+	ctx := context.Background()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newSimpleApp(fmt.Sprintf("validation-%d", i), tt.spec)
+			err := k8sClient.Create(ctx, obj)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Create() error = %v, want it to be accepted", err)
+				}
+				if err := k8sClient.Delete(ctx, obj); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Create() error = %v, want an Invalid error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimpleAppUpdateValidation(t *testing.T) {
+	ctx := context.Background()
+	obj := newSimpleApp("update-validation", map[string]interface{}{"appName": "my-app", "replicas": 1})
+	if err := k8sClient.Create(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := k8sClient.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			t.Error(err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		field   string
+		value   interface{}
+		wantErr string
+	}{
+		{
+			name:  "scale",
+			field: "replicas",
+			value: int64(5),
+		},
+		{
+			name:    "rename",
+			field:   "appName",
+			value:   "other-app",
+			wantErr: "appName is immutable",
+		},
+		{
+			name:    "scale out of range",
+			field:   "replicas",
+			value:   int64(20),
+			wantErr: "spec.replicas: Invalid value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := obj.DeepCopy()
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+				t.Fatal(err)
+			}
+			if err := unstructured.SetNestedField(current.Object, tt.value, "spec", tt.field); err != nil {
+				t.Fatal(err)
+			}
+			err := k8sClient.Update(ctx, current)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Update() error = %v, want it to be accepted", err)
+				}
+				return
+			}
+			if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Update() error = %v, want an Invalid error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -6,14 +6,23 @@ import (
 	"context"
 	"fmt"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	examplecomv1 "simpleapp-controller/api/v1"
 )
 
+// defaultImage is the container image run by the Deployment of every SimpleApp
+const defaultImage = "nginx:latest"
+
 // SimpleAppReconciler is the main controller type that implements the reconciliation logic
 // for SimpleApp custom resources.
 type SimpleAppReconciler struct {
@@ -25,9 +34,10 @@ type SimpleAppReconciler struct {
 // These annotations are used by kubebuilder to generate the RBAC manifests
 //+kubebuilder:rbac:groups=example.com,resources=simpleapps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=example.com,resources=simpleapps/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is the main reconciliation loop for SimpleApp resources.
-// It is called whenever a SimpleApp is created, updated, or deleted.
+// It is called whenever a SimpleApp or a Deployment it owns is created, updated, or deleted.
 func (r *SimpleAppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	// Get a logger with context for structured logging
 	logger := log.FromContext(ctx)
@@ -36,21 +46,142 @@ func (r *SimpleAppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	simpleApp := &examplecomv1.SimpleApp{}
 	err := r.Get(ctx, req.NamespacedName, simpleApp)
 	if err != nil {
-		// If the resource is not found, return without error
-		// This handles the case where the resource was deleted
-		logger.Error(err, "unable to fetch SimpleApp")
+		// If the resource is not found it was deleted. Its Deployment has an
+		// owner reference to it and is removed by the garbage collector.
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Log the SimpleApp details using both structured logging and fmt.Printf
-	// This demonstrates two different logging approaches
-	logger.Info("Hello, World!", "AppName", simpleApp.Spec.AppName, "Replicas", simpleApp.Spec.Replicas)
-	fmt.Printf("Hello, World! AppName: %s, Replicas: %d\n", simpleApp.Spec.AppName, simpleApp.Spec.Replicas)
+	// Nothing to do while the SimpleApp is being deleted
+	if !simpleApp.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Create the Deployment, or bring it in line with the spec
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      simpleApp.Spec.AppName,
+			Namespace: simpleApp.Namespace,
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+		// Never take over a Deployment that belongs to someone else
+		if deployment.ResourceVersion != "" && !metav1.IsControlledBy(deployment, simpleApp) {
+			return fmt.Errorf("deployment %s/%s already exists and is not owned by SimpleApp %s",
+				deployment.Namespace, deployment.Name, simpleApp.Name)
+		}
+		mutateDeployment(simpleApp, deployment)
+		// Owning the Deployment makes the garbage collector delete it with the SimpleApp
+		return ctrl.SetControllerReference(simpleApp, deployment, r.Scheme)
+	})
+	if err != nil {
+		logger.Error(err, "unable to reconcile Deployment", "Deployment", deployment.Name)
+		// Report the failure on the SimpleApp before retrying
+		if statusErr := r.updateStatus(ctx, simpleApp, nil, err); statusErr != nil {
+			logger.Error(statusErr, "unable to update SimpleApp status")
+		}
+		return ctrl.Result{}, err
+	}
+	if result != controllerutil.OperationResultNone {
+		logger.Info("Deployment reconciled", "Deployment", deployment.Name, "operation", result,
+			"Replicas", simpleApp.Spec.Replicas)
+	}
+
+	// Report the state of the Deployment on the SimpleApp. Changes of the
+	// Deployment status trigger a new reconciliation through the Owns watch.
+	if err := r.updateStatus(ctx, simpleApp, deployment, nil); err != nil {
+		logger.Error(err, "unable to update SimpleApp status")
+		return ctrl.Result{}, err
+	}
 
 	// Return no error and no requeue, indicating successful reconciliation
 	return ctrl.Result{}, nil
 }
 
+// updateStatus sets the status of simpleApp from its Deployment, or from
+// reconcileErr when the Deployment could not be reconciled, and writes it
+// through the status subresource when it changed.
+func (r *SimpleAppReconciler) updateStatus(ctx context.Context, simpleApp *examplecomv1.SimpleApp, deployment *appsv1.Deployment, reconcileErr error) error {
+	status := simpleApp.Status.DeepCopy()
+	status.ObservedGeneration = simpleApp.Generation
+
+	ready := metav1.Condition{
+		Type:               examplecomv1.ConditionReady,
+		ObservedGeneration: simpleApp.Generation,
+	}
+	switch {
+	case reconcileErr != nil:
+		status.Phase = examplecomv1.SimpleAppFailed
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "ReconcileError"
+		ready.Message = reconcileErr.Error()
+	default:
+		status.ReadyReplicas = deployment.Status.ReadyReplicas
+		status.Phase = deploymentPhase(simpleApp, deployment)
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = string(status.Phase)
+		ready.Message = fmt.Sprintf("%d of %d replicas ready", status.ReadyReplicas, simpleApp.Spec.Replicas)
+		if status.Phase == examplecomv1.SimpleAppRunning {
+			ready.Status = metav1.ConditionTrue
+		}
+	}
+	// Keeps the last transition time when the condition status is unchanged
+	meta.SetStatusCondition(&status.Conditions, ready)
+
+	if equality.Semantic.DeepEqual(&simpleApp.Status, status) {
+		return nil
+	}
+	simpleApp.Status = *status
+	return r.Status().Update(ctx, simpleApp)
+}
+
+// deploymentPhase returns the phase of simpleApp given the state of its Deployment.
+func deploymentPhase(simpleApp *examplecomv1.SimpleApp, deployment *appsv1.Deployment) examplecomv1.SimpleAppPhase {
+	desired := simpleApp.Spec.Replicas
+	rolledOut := deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == desired &&
+		deployment.Status.Replicas == desired
+	switch {
+	case rolledOut && deployment.Status.ReadyReplicas >= desired:
+		return examplecomv1.SimpleAppRunning
+	case deployment.Status.ReadyReplicas == 0:
+		return examplecomv1.SimpleAppPending
+	default:
+		return examplecomv1.SimpleAppProgressing
+	}
+}
+
+// mutateDeployment sets the fields of deployment managed by the controller
+// from the spec of simpleApp, leaving the fields defaulted by the API server alone.
+func mutateDeployment(simpleApp *examplecomv1.SimpleApp, deployment *appsv1.Deployment) {
+	labels := map[string]string{"app": deployment.Name}
+
+	if deployment.Labels == nil {
+		deployment.Labels = map[string]string{}
+	}
+	deployment.Labels["app"] = deployment.Name
+
+	replicas := simpleApp.Spec.Replicas
+	deployment.Spec.Replicas = &replicas
+	// The selector is immutable, so it is only set on creation
+	if deployment.Spec.Selector == nil {
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	}
+
+	template := &deployment.Spec.Template
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		template.Labels[k] = v
+	}
+	if len(template.Spec.Containers) != 1 {
+		template.Spec.Containers = []corev1.Container{{}}
+	}
+	container := &template.Spec.Containers[0]
+	container.Name = deployment.Name
+	container.Image = defaultImage
+}
+
 // SetupWithManager sets up the controller with the Manager.
 // This method is called by the main function to register the controller
 // with the controller-runtime manager.
@@ -58,6 +189,9 @@ func (r *SimpleAppReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		// Watch for changes to SimpleApp resources
 		For(&examplecomv1.SimpleApp{}).
+		// Watch the Deployments owned by a SimpleApp, so that changes made to
+		// them directly are reverted
+		Owns(&appsv1.Deployment{}).
 		// Complete the controller setup
 		Complete(r)
 }
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	examplecomv1 "simpleapp-controller/api/v1"
+)
+
+// eventually calls check until it returns nil or the timeout expires
+func eventually(t *testing.T, what string, check func() error) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		err := check()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: %v", what, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// TestSimpleAppControllerEnvtest runs the controller in a manager against a
+// local API server started by envtest, which serves the generated CRD but
+// runs no other controllers: Deployment statuses are set by the test.
+func TestSimpleAppControllerEnvtest(t *testing.T) {
+	// The logger is global and outlives this test, so it cannot log through t
+	logOutput := io.Discard
+	if testing.Verbose() {
+		logOutput = os.Stderr
+	}
+	logf.SetLogger(zap.New(zap.WriteTo(logOutput), zap.UseDevMode(true)))
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "simpleapp-crd.yaml")},
+		ErrorIfCRDPathMissing: true,
+		// Used when KUBEBUILDER_ASSETS is not set, see the test target of the Makefile
+		BinaryAssetsDirectory: filepath.Join("..", "bin", "k8s",
+			fmt.Sprintf("1.29.0-%s-%s", goruntime.GOOS, goruntime.GOARCH)),
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("starting envtest: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := testEnv.Stop(); err != nil {
+			t.Errorf("stopping envtest: %v", err)
+		}
+	})
+
+	scheme := newScheme(t)
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:  scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&SimpleAppReconciler{Client: mgr.GetClient(), Scheme: scheme}).SetupWithManager(mgr); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- mgr.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("manager: %v", err)
+		}
+	})
+
+	// Read directly from the API server rather than from the manager cache
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appKey := types.NamespacedName{Namespace: "default", Name: "my-simple-app"}
+	deploymentKey := types.NamespacedName{Namespace: "default", Name: "my-app"}
+
+	simpleApp := newSimpleApp("my-app", 2)
+	simpleApp.UID = ""
+	simpleApp.Generation = 0
+	if err := k8sClient.Create(ctx, simpleApp); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("creates the Deployment", func(t *testing.T) {
+		eventually(t, "Deployment", func() error {
+			deployment := &appsv1.Deployment{}
+			if err := k8sClient.Get(ctx, deploymentKey, deployment); err != nil {
+				return err
+			}
+			if got := ptr.Deref(deployment.Spec.Replicas, 0); got != 2 {
+				return fmt.Errorf("replicas = %d, want 2", got)
+			}
+			if !metav1.IsControlledBy(deployment, simpleApp) {
+				return fmt.Errorf("owner references = %+v", deployment.OwnerReferences)
+			}
+			return nil
+		})
+		eventually(t, "Pending status", func() error {
+			return expectPhase(ctx, k8sClient, appKey, examplecomv1.SimpleAppPending, metav1.ConditionFalse)
+		})
+	})
+
+	t.Run("reports the Deployment status", func(t *testing.T) {
+		deployment := &appsv1.Deployment{}
+		if err := k8sClient.Get(ctx, deploymentKey, deployment); err != nil {
+			t.Fatal(err)
+		}
+		deployment.Status = appsv1.DeploymentStatus{
+			ObservedGeneration: deployment.Generation,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			ReadyReplicas:      2,
+			AvailableReplicas:  2,
+		}
+		if err := k8sClient.Status().Update(ctx, deployment); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, "Running status", func() error {
+			return expectPhase(ctx, k8sClient, appKey, examplecomv1.SimpleAppRunning, metav1.ConditionTrue)
+		})
+	})
+
+	t.Run("scales the Deployment", func(t *testing.T) {
+		current := &examplecomv1.SimpleApp{}
+		if err := k8sClient.Get(ctx, appKey, current); err != nil {
+			t.Fatal(err)
+		}
+		current.Spec.Replicas = 4
+		if err := k8sClient.Update(ctx, current); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, "scaled Deployment", func() error {
+			deployment := &appsv1.Deployment{}
+			if err := k8sClient.Get(ctx, deploymentKey, deployment); err != nil {
+				return err
+			}
+			if got := ptr.Deref(deployment.Spec.Replicas, 0); got != 4 {
+				return fmt.Errorf("replicas = %d, want 4", got)
+			}
+			return nil
+		})
+		eventually(t, "Progressing status", func() error {
+			return expectPhase(ctx, k8sClient, appKey, examplecomv1.SimpleAppProgressing, metav1.ConditionFalse)
+		})
+	})
+
+	t.Run("reverts changes to the Deployment", func(t *testing.T) {
+		deployment := &appsv1.Deployment{}
+		if err := k8sClient.Get(ctx, deploymentKey, deployment); err != nil {
+			t.Fatal(err)
+		}
+		deployment.Spec.Template.Spec.Containers[0].Image = "busybox"
+		if err := k8sClient.Update(ctx, deployment); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, "reverted Deployment", func() error {
+			deployment := &appsv1.Deployment{}
+			if err := k8sClient.Get(ctx, deploymentKey, deployment); err != nil {
+				return err
+			}
+			if got := deployment.Spec.Template.Spec.Containers[0].Image; got != defaultImage {
+				return fmt.Errorf("image = %q, want %q", got, defaultImage)
+			}
+			return nil
+		})
+	})
+}
+
+// expectPhase returns an error unless the SimpleApp at key reports phase and
+// a Ready condition with status ready for its current generation
+func expectPhase(ctx context.Context, c client.Client, key types.NamespacedName, phase examplecomv1.SimpleAppPhase, ready metav1.ConditionStatus) error {
+	simpleApp := &examplecomv1.SimpleApp{}
+	if err := c.Get(ctx, key, simpleApp); err != nil {
+		return err
+	}
+	if simpleApp.Status.ObservedGeneration != simpleApp.Generation {
+		return fmt.Errorf("observedGeneration = %d, want %d", simpleApp.Status.ObservedGeneration, simpleApp.Generation)
+	}
+	if simpleApp.Status.Phase != phase {
+		return fmt.Errorf("phase = %q, want %q", simpleApp.Status.Phase, phase)
+	}
+	if !meta.IsStatusConditionPresentAndEqual(simpleApp.Status.Conditions, examplecomv1.ConditionReady, ready) {
+		return fmt.Errorf("conditions = %+v, want Ready %s", simpleApp.Status.Conditions, ready)
+	}
+	return nil
+}
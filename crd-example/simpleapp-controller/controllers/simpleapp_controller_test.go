@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	examplecomv1 "simpleapp-controller/api/v1"
+)
+
+// newScheme returns a scheme with the built-in and the SimpleApp types
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := examplecomv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+// newSimpleApp returns a SimpleApp named my-simple-app in the default namespace
+func newSimpleApp(appName string, replicas int32) *examplecomv1.SimpleApp {
+	return &examplecomv1.SimpleApp{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "my-simple-app",
+			Namespace:  "default",
+			UID:        "simpleapp-uid",
+			Generation: 1,
+		},
+		Spec: examplecomv1.SimpleAppSpec{AppName: appName, Replicas: replicas},
+	}
+}
+
+// newReconciler returns a SimpleAppReconciler backed by a fake client
+// holding objs
+func newReconciler(t *testing.T, objs ...client.Object) *SimpleAppReconciler {
+	t.Helper()
+	scheme := newScheme(t)
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&examplecomv1.SimpleApp{}, &appsv1.Deployment{}).
+		Build()
+	return &SimpleAppReconciler{Client: c, Scheme: scheme}
+}
+
+// reconcile runs one reconciliation of the SimpleApp named my-simple-app
+func reconcile(t *testing.T, r *SimpleAppReconciler) error {
+	t.Helper()
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-simple-app"},
+	})
+	if result != (ctrl.Result{}) {
+		t.Errorf("Reconcile() result = %+v, want no requeue", result)
+	}
+	return err
+}
+
+func getSimpleApp(t *testing.T, r *SimpleAppReconciler) *examplecomv1.SimpleApp {
+	t.Helper()
+	simpleApp := &examplecomv1.SimpleApp{}
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "my-simple-app"}, simpleApp); err != nil {
+		t.Fatal(err)
+	}
+	return simpleApp
+}
+
+func getDeployment(t *testing.T, r *SimpleAppReconciler, name string) *appsv1.Deployment {
+	t.Helper()
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, deployment); err != nil {
+		t.Fatal(err)
+	}
+	return deployment
+}
+
+// setDeploymentStatus plays the deployment controller, which the fake client
+// does not run. The fake client does not maintain metadata.generation either,
+// so it is set to generation.
+func setDeploymentStatus(t *testing.T, r *SimpleAppReconciler, name string, generation int64, status appsv1.DeploymentStatus) {
+	t.Helper()
+	deployment := getDeployment(t, r, name)
+	deployment.Generation = generation
+	if err := r.Update(context.Background(), deployment); err != nil {
+		t.Fatal(err)
+	}
+	deployment.Status = status
+	if err := r.Status().Update(context.Background(), deployment); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileNotFound(t *testing.T) {
+	r := newReconciler(t)
+	if err := reconcile(t, r); err != nil {
+		t.Fatalf("Reconcile() error = %v, want a deleted SimpleApp to be ignored", err)
+	}
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(context.Background(), deployments); err != nil {
+		t.Fatal(err)
+	}
+	if len(deployments.Items) != 0 {
+		t.Errorf("Deployments = %d, want none", len(deployments.Items))
+	}
+}
+
+func TestReconcileBeingDeleted(t *testing.T) {
+	simpleApp := newSimpleApp("my-app", 1)
+	simpleApp.DeletionTimestamp = ptr.To(metav1.Now())
+	simpleApp.Finalizers = []string{"example.com/test"}
+	r := newReconciler(t, simpleApp)
+
+	if err := reconcile(t, r); err != nil {
+		t.Fatal(err)
+	}
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(context.Background(), deployments); err != nil {
+		t.Fatal(err)
+	}
+	if len(deployments.Items) != 0 {
+		t.Errorf("Deployments = %d, want none for a SimpleApp being deleted", len(deployments.Items))
+	}
+}
+
+func TestReconcileCreate(t *testing.T) {
+	r := newReconciler(t, newSimpleApp("my-app", 3))
+	if err := reconcile(t, r); err != nil {
+		t.Fatal(err)
+	}
+
+	deployment := getDeployment(t, r, "my-app")
+	if got := ptr.Deref(deployment.Spec.Replicas, 0); got != 3 {
+		t.Errorf("replicas = %d, want 3", got)
+	}
+	if got := deployment.Spec.Selector.MatchLabels; len(got) != 1 || got["app"] != "my-app" {
+		t.Errorf("selector = %v, want app=my-app", got)
+	}
+	if got := deployment.Spec.Template.Labels["app"]; got != "my-app" {
+		t.Errorf("pod label app = %q, want my-app", got)
+	}
+	containers := deployment.Spec.Template.Spec.Containers
+	if len(containers) != 1 || containers[0].Name != "my-app" || containers[0].Image != defaultImage {
+		t.Errorf("containers = %+v, want one my-app container running %s", containers, defaultImage)
+	}
+	if !metav1.IsControlledBy(deployment, getSimpleApp(t, r)) {
+		t.Errorf("owner references = %+v, want the SimpleApp as controller", deployment.OwnerReferences)
+	}
+}
+
+func TestReconcileUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*SimpleAppReconciler, *appsv1.Deployment, *examplecomv1.SimpleApp) error
+	}{
+		{
+			name: "scale the SimpleApp",
+			modify: func(r *SimpleAppReconciler, _ *appsv1.Deployment, simpleApp *examplecomv1.SimpleApp) error {
+				simpleApp.Spec.Replicas = 5
+				return r.Update(context.Background(), simpleApp)
+			},
+		},
+		{
+			name: "scale the Deployment directly",
+			modify: func(r *SimpleAppReconciler, deployment *appsv1.Deployment, simpleApp *examplecomv1.SimpleApp) error {
+				simpleApp.Spec.Replicas = 5
+				if err := r.Update(context.Background(), simpleApp); err != nil {
+					return err
+				}
+				deployment.Spec.Replicas = ptr.To(int32(1))
+				return r.Update(context.Background(), deployment)
+			},
+		},
+		{
+			name: "change the image of the Deployment",
+			modify: func(r *SimpleAppReconciler, deployment *appsv1.Deployment, simpleApp *examplecomv1.SimpleApp) error {
+				simpleApp.Spec.Replicas = 5
+				if err := r.Update(context.Background(), simpleApp); err != nil {
+					return err
+				}
+				deployment.Spec.Template.Spec.Containers[0].Image = "busybox"
+				return r.Update(context.Background(), deployment)
+			},
+		},
+		{
+			name: "add a container to the Deployment",
+			modify: func(r *SimpleAppReconciler, deployment *appsv1.Deployment, simpleApp *examplecomv1.SimpleApp) error {
+				simpleApp.Spec.Replicas = 5
+				if err := r.Update(context.Background(), simpleApp); err != nil {
+					return err
+				}
+				deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers,
+					corev1.Container{Name: "sidecar", Image: "busybox"})
+				return r.Update(context.Background(), deployment)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReconciler(t, newSimpleApp("my-app", 2))
+			if err := reconcile(t, r); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.modify(r, getDeployment(t, r, "my-app"), getSimpleApp(t, r)); err != nil {
+				t.Fatal(err)
+			}
+			if err := reconcile(t, r); err != nil {
+				t.Fatal(err)
+			}
+
+			deployment := getDeployment(t, r, "my-app")
+			if got := ptr.Deref(deployment.Spec.Replicas, 0); got != 5 {
+				t.Errorf("replicas = %d, want 5", got)
+			}
+			containers := deployment.Spec.Template.Spec.Containers
+			if len(containers) != 1 || containers[0].Image != defaultImage {
+				t.Errorf("containers = %+v, want one container running %s", containers, defaultImage)
+			}
+		})
+	}
+}
+
+func TestReconcileUnownedDeployment(t *testing.T) {
+	existing := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(int32(7)),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "other"}},
+		},
+	}
+	r := newReconciler(t, newSimpleApp("my-app", 3), existing)
+
+	err := reconcile(t, r)
+	if err == nil || !strings.Contains(err.Error(), "not owned by SimpleApp") {
+		t.Fatalf("Reconcile() error = %v, want an ownership error", err)
+	}
+
+	deployment := getDeployment(t, r, "my-app")
+	if got := ptr.Deref(deployment.Spec.Replicas, 0); got != 7 || len(deployment.OwnerReferences) != 0 {
+		t.Errorf("deployment was modified: replicas = %d, owners = %+v", got, deployment.OwnerReferences)
+	}
+
+	simpleApp := getSimpleApp(t, r)
+	if simpleApp.Status.Phase != examplecomv1.SimpleAppFailed {
+		t.Errorf("phase = %q, want %q", simpleApp.Status.Phase, examplecomv1.SimpleAppFailed)
+	}
+	ready := meta.FindStatusCondition(simpleApp.Status.Conditions, examplecomv1.ConditionReady)
+	if ready == nil || ready.Status != metav1.ConditionFalse || ready.Reason != "ReconcileError" {
+		t.Errorf("Ready condition = %+v, want False with reason ReconcileError", ready)
+	}
+}
+
+func TestReconcileStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		generation  int64
+		status      appsv1.DeploymentStatus
+		wantPhase   examplecomv1.SimpleAppPhase
+		wantReady   metav1.ConditionStatus
+		wantMessage string
+	}{
+		{
+			name:        "no pod ready",
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3},
+			wantPhase:   examplecomv1.SimpleAppPending,
+			wantReady:   metav1.ConditionFalse,
+			wantMessage: "0 of 3 replicas ready",
+		},
+		{
+			name:        "some pods ready",
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 2},
+			wantPhase:   examplecomv1.SimpleAppProgressing,
+			wantReady:   metav1.ConditionFalse,
+			wantMessage: "2 of 3 replicas ready",
+		},
+		{
+			name:        "rolling out",
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 4, UpdatedReplicas: 1, ReadyReplicas: 3},
+			wantPhase:   examplecomv1.SimpleAppProgressing,
+			wantReady:   metav1.ConditionFalse,
+			wantMessage: "3 of 3 replicas ready",
+		},
+		{
+			name:        "spec not observed yet",
+			generation:  2,
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3},
+			wantPhase:   examplecomv1.SimpleAppProgressing,
+			wantReady:   metav1.ConditionFalse,
+			wantMessage: "3 of 3 replicas ready",
+		},
+		{
+			name:        "all pods ready",
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3},
+			wantPhase:   examplecomv1.SimpleAppRunning,
+			wantReady:   metav1.ConditionTrue,
+			wantMessage: "3 of 3 replicas ready",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReconciler(t, newSimpleApp("my-app", 3))
+			if err := reconcile(t, r); err != nil {
+				t.Fatal(err)
+			}
+			generation := tt.generation
+			if generation == 0 {
+				generation = 1
+			}
+			setDeploymentStatus(t, r, "my-app", generation, tt.status)
+			if err := reconcile(t, r); err != nil {
+				t.Fatal(err)
+			}
+
+			simpleApp := getSimpleApp(t, r)
+			if simpleApp.Status.Phase != tt.wantPhase {
+				t.Errorf("phase = %q, want %q", simpleApp.Status.Phase, tt.wantPhase)
+			}
+			if simpleApp.Status.ReadyReplicas != tt.status.ReadyReplicas {
+				t.Errorf("readyReplicas = %d, want %d", simpleApp.Status.ReadyReplicas, tt.status.ReadyReplicas)
+			}
+			if simpleApp.Status.ObservedGeneration != simpleApp.Generation {
+				t.Errorf("observedGeneration = %d, want %d", simpleApp.Status.ObservedGeneration, simpleApp.Generation)
+			}
+			ready := meta.FindStatusCondition(simpleApp.Status.Conditions, examplecomv1.ConditionReady)
+			if ready == nil || ready.Status != tt.wantReady || ready.Reason != string(tt.wantPhase) || ready.Message != tt.wantMessage {
+				t.Errorf("Ready condition = %+v, want %s with reason %s and message %q", ready, tt.wantReady, tt.wantPhase, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestReconcileStatusUnchanged(t *testing.T) {
+	r := newReconciler(t, newSimpleApp("my-app", 1))
+	if err := reconcile(t, r); err != nil {
+		t.Fatal(err)
+	}
+	setDeploymentStatus(t, r, "my-app", 1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1})
+	if err := reconcile(t, r); err != nil {
+		t.Fatal(err)
+	}
+	before := getSimpleApp(t, r)
+
+	if err := reconcile(t, r); err != nil {
+		t.Fatal(err)
+	}
+	after := getSimpleApp(t, r)
+	if after.ResourceVersion != before.ResourceVersion {
+		t.Errorf("resourceVersion changed from %s to %s, want no write when nothing changed",
+			before.ResourceVersion, after.ResourceVersion)
+	}
+}
@@ -3,8 +3,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"net/http"
 	"os"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -13,6 +17,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	examplecomv1 "simpleapp-controller/api/v1"
 	"simpleapp-controller/controllers"
@@ -23,6 +28,9 @@ var (
 	scheme = runtime.NewScheme()
 )
 
+// cacheSyncTimeout bounds how long a readiness probe waits for the informer caches
+const cacheSyncTimeout = time.Second
+
 // init registers the Kubernetes API schemes with our runtime scheme
 func init() {
 	// Add standard Kubernetes types to the scheme
@@ -37,20 +45,36 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
-	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	var gracefulShutdownTimeout time.Duration
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to. Use 0 to disable it.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Enable leader election for controller manager.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 30*time.Second,
+		"How long to wait for running reconciliations to finish on shutdown. Use 0 to stop immediately, or -1 to wait forever.")
+	// Logging flags: --zap-devel, --zap-log-level, --zap-encoder, --zap-stacktrace-level, --zap-time-encoding
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	// Set up structured logging using zap
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
 	// Create a new controller manager with the specified options
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                        scheme,
-		HealthProbeBindAddress:        probeAddr,
-		LeaderElection:               enableLeaderElection,
-		LeaderElectionID:             "simpleapp-controller.example.com",
+		Scheme: scheme,
+		// Serve the controller-runtime and workqueue metrics in the Prometheus format
+		Metrics: metricsserver.Options{
+			BindAddress: metricsAddr,
+		},
+		HealthProbeBindAddress: probeAddr,
+		LeaderElection:         enableLeaderElection,
+		LeaderElectionID:       "simpleapp-controller.example.com",
+		// Hand over leadership as soon as the manager stops, instead of
+		// letting the next leader wait for the lease to expire
+		LeaderElectionReleaseOnCancel: true,
+		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
 	})
 	if err != nil {
 		ctrl.Log.Error(err, "unable to start manager")
@@ -71,15 +95,33 @@ func main() {
 		ctrl.Log.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	// Only report ready once the informer caches are filled, so that a new
+	// replica does not receive traffic while it still has a partial view of the cluster
+	if err := mgr.AddReadyzCheck("readyz", cacheSyncCheck(mgr)); err != nil {
 		ctrl.Log.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
 
-	// Start the controller manager
+	// Start the controller manager. On SIGTERM or SIGINT it stops watching,
+	// waits up to the graceful shutdown timeout for running reconciliations
+	// and releases the leader election lease.
 	ctrl.Log.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		ctrl.Log.Error(err, "problem running manager")
 		os.Exit(1)
 	}
-} 
\ No newline at end of file
+	ctrl.Log.Info("manager stopped")
+}
+
+// cacheSyncCheck returns a readiness check that fails until the informer
+// caches of mgr have synced.
+func cacheSyncCheck(mgr ctrl.Manager) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), cacheSyncTimeout)
+		defer cancel()
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			return errors.New("informer caches have not synced")
+		}
+		return nil
+	}
+}
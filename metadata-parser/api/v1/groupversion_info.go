@@ -0,0 +1,11 @@
+// Package v1 contains API Schema definitions for the webapp v1 API group
+// +kubebuilder:object:generate=true
+// +groupName=webapp.my.domain
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersion is group version used to register these objects
+var GroupVersion = schema.GroupVersion{Group: "webapp.my.domain", Version: "v1"}
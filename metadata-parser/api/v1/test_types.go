@@ -66,4 +66,23 @@ type TestResourceStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Message explains the phase
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Reason directly follows Message and has no markers of its own
+	Reason string `json:"reason,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TestResourceList contains a list of TestResource. The marker above is
+// separated from this comment by a blank line, as kubebuilder scaffolds it,
+// and still belongs to TestResourceList. This comment is also longer than
+// three lines, so the marker is further away from the declaration than
+// markers usually are.
+type TestResourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TestResource `json:"items"`
 }
@@ -1,62 +1,107 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
+	"go/types"
 	"log"
 	"os"
+	"reflect"
 	"strings"
 )
 
+// Result is the document printed by --output json and --output yaml. Fields
+// are only ever added to it, so scripts can rely on the existing ones.
+type Result struct {
+	Packages []PackageInfo `json:"packages"`
+	Structs  []StructInfo  `json:"structs"`
+}
+
+type PackageInfo struct {
+	// Path is the import path of the package, e.g. metadata-parser/api/v1
+	Path string `json:"path"`
+	Name string `json:"name"`
+	// Markers are the package-level markers such as groupName, from the
+	// package doc comments of all its files
+	Markers []string `json:"markers"`
+}
+
 type StructInfo struct {
-	Name    string
-	Markers []string
-	Fields  []FieldInfo
+	Name string `json:"name"`
+	// Package is the import path of the package declaring the struct
+	Package string      `json:"package"`
+	File    string      `json:"file"`
+	Line    int         `json:"line"`
+	Markers []string    `json:"markers"`
+	Fields  []FieldInfo `json:"fields"`
 }
 
 type FieldInfo struct {
-	Name    string
-	Markers []string
+	Name string `json:"name"`
+	// JSONName is the name from the json struct tag, empty if there is none
+	JSONName string `json:"jsonName"`
+	// Type is the Go type as written in the source, e.g. []metav1.Condition
+	Type    string   `json:"type"`
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Markers []string `json:"markers"`
 }
 
 func main() {
-	// Default file path
-	filePath := "api/v1/types.go"
+	output := flag.String("output", "text", "output format: text, json or yaml")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [--output text|json|yaml] [file|directory|package|pattern ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-	// Check if a file path is provided as command line argument
-	if len(os.Args) > 1 {
-		filePath = os.Args[1]
+	if *output != "text" && *output != "json" && *output != "yaml" {
+		log.Fatalf("Unknown output format %q, must be text, json or yaml", *output)
 	}
 
-	// Create a new token set
-	fset := token.NewFileSet()
+	// Default file path
+	args := []string{"api/v1/types.go"}
+
+	// Check if files, directories or packages are provided as command line arguments
+	if flag.NArg() > 0 {
+		args = flag.Args()
+	}
 
-	// Parse the file
-	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
+	result, err := load(args)
 	if err != nil {
-		log.Fatalf("Failed to parse file %s: %v", filePath, err)
+		log.Fatalf("Failed to load %s: %v", strings.Join(args, " "), err)
 	}
 
-	fmt.Printf("🔍 Parsing kubebuilder metadata from file: %s\n\n", filePath)
+	switch *output {
+	case "json":
+		err = printJSON(os.Stdout, result)
+	case "yaml":
+		err = printYAML(os.Stdout, result)
+	default:
+		printText(os.Stdout, args, result)
+	}
+	if err != nil {
+		log.Fatalf("Failed to print results: %v", err)
+	}
+}
 
-	var structs []StructInfo
+// parseFile collects the structs of a parsed Go file together with the
+// kubebuilder markers associated with them
+func parseFile(fset *token.FileSet, node *ast.File, markers *fileMarkers, pkgPath, filePath string) []StructInfo {
+	structs := []StructInfo{}
 
-	// First pass: collect all kubebuilder markers and their positions
-	markerPositions := make(map[int][]string)
-	for _, commentGroup := range node.Comments {
-		for _, comment := range commentGroup.List {
-			text := comment.Text
-			if strings.HasPrefix(text, "// +") {
-				markerText := strings.TrimSpace(strings.TrimPrefix(text, "// +"))
-				pos := fset.Position(comment.Pos()).Line
-				markerPositions[pos] = append(markerPositions[pos], markerText)
-			}
+	// markersOf returns the markers of a type or field, which is never nil so
+	// that it is printed as an empty list
+	markersOf := func(n ast.Node) []string {
+		if found, ok := markers.nodes[n]; ok {
+			return found
 		}
+		return []string{}
 	}
 
-	// Second pass: find type declarations and associate markers
+	// Find type declarations and look up their markers
 	ast.Inspect(node, func(n ast.Node) bool {
 		// We are only interested in type declarations
 		typeSpec, ok := n.(*ast.TypeSpec)
@@ -65,44 +110,34 @@ func main() {
 		}
 
 		// We are only interested in struct types
-		_, ok = typeSpec.Type.(*ast.StructType)
+		structType, ok := typeSpec.Type.(*ast.StructType)
 		if !ok {
 			return true
 		}
 
+		structPos := fset.Position(typeSpec.Pos())
 		structInfo := StructInfo{
 			Name:    typeSpec.Name.Name,
-			Markers: []string{},
+			Package: pkgPath,
+			File:    filePath,
+			Line:    structPos.Line,
+			Markers: markersOf(typeSpec),
 			Fields:  []FieldInfo{},
 		}
 
-		// Find markers associated with this struct
-		structPos := fset.Position(typeSpec.Pos()).Line
-		for line, markers := range markerPositions {
-			// Check if markers are within 3 lines before the struct declaration
-			if line >= structPos-3 && line < structPos {
-				structInfo.Markers = append(structInfo.Markers, markers...)
-			}
-		}
-
 		// Process fields
-		if structType, ok := typeSpec.Type.(*ast.StructType); ok && structType.Fields != nil {
+		if structType.Fields != nil {
 			for _, field := range structType.Fields.List {
 				if len(field.Names) > 0 {
+					fieldPos := fset.Position(field.Pos())
 					fieldInfo := FieldInfo{
-						Name:    field.Names[0].Name,
-						Markers: []string{},
+						Name:     field.Names[0].Name,
+						JSONName: jsonName(field.Tag),
+						Type:     types.ExprString(field.Type),
+						File:     filePath,
+						Line:     fieldPos.Line,
+						Markers:  markersOf(field),
 					}
-
-					// Find markers associated with this field
-					fieldPos := fset.Position(field.Pos()).Line
-					for line, markers := range markerPositions {
-						// Check if markers are within 2 lines before the field declaration
-						if line >= fieldPos-2 && line < fieldPos {
-							fieldInfo.Markers = append(fieldInfo.Markers, markers...)
-						}
-					}
-
 					structInfo.Fields = append(structInfo.Fields, fieldInfo)
 				}
 			}
@@ -112,46 +147,14 @@ func main() {
 		return true
 	})
 
-	// Display results
-	fmt.Printf("📊 Found %d struct(s) with kubebuilder metadata:\n\n", len(structs))
-
-	for i, structInfo := range structs {
-		fmt.Printf("🏗️  Struct: %s\n", structInfo.Name)
-
-		if len(structInfo.Markers) > 0 {
-			fmt.Printf("   📋 Struct-level markers (%d):\n", len(structInfo.Markers))
-			for _, marker := range structInfo.Markers {
-				fmt.Printf("      • %s\n", marker)
-			}
-		} else {
-			fmt.Printf("   📋 Struct-level markers: (none)\n")
-		}
-
-		fieldsWithMarkers := 0
-		for _, field := range structInfo.Fields {
-			if len(field.Markers) > 0 {
-				fieldsWithMarkers++
-			}
-		}
-
-		if len(structInfo.Fields) > 0 {
-			fmt.Printf("   🔧 Field-level markers (%d fields, %d with markers):\n", len(structInfo.Fields), fieldsWithMarkers)
-			for _, field := range structInfo.Fields {
-				if len(field.Markers) > 0 {
-					fmt.Printf("      Field: %s\n", field.Name)
-					for _, marker := range field.Markers {
-						fmt.Printf("         • %s\n", marker)
-					}
-				}
-			}
-		} else {
-			fmt.Printf("   🔧 Field-level markers: (no fields)\n")
-		}
+	return structs
+}
 
-		if i < len(structs)-1 {
-			fmt.Println("   " + strings.Repeat("-", 50))
-		}
+// jsonName returns the name given by the json key of a struct tag
+func jsonName(tag *ast.BasicLit) string {
+	if tag == nil {
+		return ""
 	}
-
-	fmt.Printf("\n✅ Parsing completed successfully!\n")
+	name, _, _ := strings.Cut(reflect.StructTag(strings.Trim(tag.Value, "`")).Get("json"), ",")
+	return name
 }
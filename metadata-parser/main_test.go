@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+// loadStructs returns the structs found by load for args
+func loadStructs(t *testing.T, args ...string) []StructInfo {
+	t.Helper()
+	result, err := load(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Structs
+}
+
+func TestParseFile(t *testing.T) {
+	structs := loadStructs(t, "api/v1/types.go")
+	if len(structs) != 3 {
+		t.Fatalf("Expected 3 structs, got %d", len(structs))
+	}
+
+	guestbook := structs[0]
+	if guestbook.Name != "Guestbook" || guestbook.Package != "metadata-parser/api/v1" ||
+		guestbook.File != "api/v1/types.go" || guestbook.Line != 10 {
+		t.Errorf("Unexpected struct %s in %s at %s:%d", guestbook.Name, guestbook.Package, guestbook.File, guestbook.Line)
+	}
+	wantMarkers := []string{"kubebuilder:object:root=true", "kubebuilder:subresource:status"}
+	if !reflect.DeepEqual(guestbook.Markers, wantMarkers) {
+		t.Errorf("Expected markers %v in source order, got %v", wantMarkers, guestbook.Markers)
+	}
+
+	wantSpec := FieldInfo{
+		Name:     "Spec",
+		JSONName: "spec",
+		Type:     "GuestbookSpec",
+		File:     "api/v1/types.go",
+		Line:     19,
+		Markers:  []string{"required"},
+	}
+	if !reflect.DeepEqual(guestbook.Fields[0], wantSpec) {
+		t.Errorf("Expected field %+v, got %+v", wantSpec, guestbook.Fields[0])
+	}
+}
+
+func TestParseFileTypes(t *testing.T) {
+	structs := loadStructs(t, "api/v1/test_types.go")
+
+	types := map[string]string{}
+	for _, structInfo := range structs {
+		for _, field := range structInfo.Fields {
+			types[structInfo.Name+"."+field.JSONName] = field.Type
+		}
+	}
+	for field, want := range map[string]string{
+		"TestResourceSpec.tags":         "[]string",
+		"TestResourceSpec.replicas":     "int32",
+		"TestResourceStatus.conditions": "[]metav1.Condition",
+	} {
+		if got := types[field]; got != want {
+			t.Errorf("Expected %s to have type %q, got %q", field, want, got)
+		}
+	}
+}
+
+// TestPrintSchema verifies that JSON and YAML use the same field names and
+// that empty lists are printed rather than left out
+func TestPrintSchema(t *testing.T) {
+	result, err := load([]string{"api/v1/types.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonOutput, yamlOutput bytes.Buffer
+	if err := printJSON(&jsonOutput, result); err != nil {
+		t.Fatal(err)
+	}
+	if err := printYAML(&yamlOutput, result); err != nil {
+		t.Fatal(err)
+	}
+
+	var fromJSON, fromYAML map[string]interface{}
+	if err := json.Unmarshal(jsonOutput.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("Failed to unmarshal JSON output: %v", err)
+	}
+	if err := yaml.Unmarshal(yamlOutput.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("Failed to unmarshal YAML output: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("JSON and YAML output differ:\n%s\n%s", jsonOutput.String(), yamlOutput.String())
+	}
+
+	status := fromJSON["structs"].([]interface{})[2].(map[string]interface{})
+	for _, key := range []string{"name", "package", "file", "line", "markers", "fields"} {
+		if _, ok := status[key]; !ok {
+			t.Errorf("Expected key %q in %v", key, status)
+		}
+	}
+	if fields := status["fields"].([]interface{}); len(fields) != 0 {
+		t.Errorf("Expected GuestbookStatus to have no fields, got %v", fields)
+	}
+}
+
+func TestLoadPackages(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantStructs []string
+	}{
+		{
+			name:        "file",
+			args:        []string{"api/v1/types.go"},
+			wantStructs: []string{"Guestbook", "GuestbookSpec", "GuestbookStatus"},
+		},
+		{
+			name: "directory",
+			args: []string{"api/v1"},
+			wantStructs: []string{"TestResource", "TestResourceSpec", "TestResourceStatus", "TestResourceList",
+				"Guestbook", "GuestbookSpec", "GuestbookStatus"},
+		},
+		{
+			name: "recursive pattern",
+			args: []string{"./api/..."},
+			wantStructs: []string{"TestResource", "TestResourceSpec", "TestResourceStatus", "TestResourceList",
+				"Guestbook", "GuestbookSpec", "GuestbookStatus"},
+		},
+		{
+			name: "file and pattern",
+			args: []string{"api/v1/types.go", "./api/..."},
+			wantStructs: []string{"TestResource", "TestResourceSpec", "TestResourceStatus", "TestResourceList",
+				"Guestbook", "GuestbookSpec", "GuestbookStatus"},
+		},
+		{
+			name: "import path",
+			args: []string{"metadata-parser/api/v1"},
+			wantStructs: []string{"TestResource", "TestResourceSpec", "TestResourceStatus", "TestResourceList",
+				"Guestbook", "GuestbookSpec", "GuestbookStatus"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := load(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The package markers live in groupversion_info.go, so they are
+			// reported even when only types.go is parsed
+			wantPackages := []PackageInfo{{
+				Path:    "metadata-parser/api/v1",
+				Name:    "v1",
+				Markers: []string{"kubebuilder:object:generate=true", "groupName=webapp.my.domain"},
+			}}
+			if !reflect.DeepEqual(result.Packages, wantPackages) {
+				t.Errorf("Expected packages %+v, got %+v", wantPackages, result.Packages)
+			}
+
+			var structs []string
+			for _, structInfo := range result.Structs {
+				structs = append(structs, structInfo.Name)
+			}
+			if !reflect.DeepEqual(structs, tt.wantStructs) {
+				t.Errorf("Expected structs %v, got %v", tt.wantStructs, structs)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"api/v1/missing.go"},
+		{"./missing/..."},
+		{"metadata-parser/missing"},
+	} {
+		if _, err := load(args); err == nil {
+			t.Errorf("Expected an error loading %v", args)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// fileMarkers are the kubebuilder markers of a file, associated with the
+// declarations they describe the way controller-gen does it:
+//   - a type or field gets the markers of its doc comment, plus those of the
+//     closest comment group before it, which may be separated from the doc
+//     comment by a blank line
+//   - the markers of the package doc comment, and of any other comment group
+//     in the file that does not belong to a type or field, describe the package
+type fileMarkers struct {
+	fset     *token.FileSet
+	comments []*ast.CommentGroup
+
+	pkg   []string
+	nodes map[ast.Node][]string
+}
+
+// associateMarkers collects the markers of file
+func associateMarkers(fset *token.FileSet, file *ast.File) *fileMarkers {
+	m := &fileMarkers{
+		fset:     fset,
+		comments: file.Comments,
+		pkg:      []string{},
+		nodes:    map[ast.Node][]string{},
+	}
+
+	// Everything before the package clause describes the package
+	free, closest := m.groupsBefore(token.NoPos, file.Package, file.Doc)
+	m.pkg = append(m.pkg, markersIn(append(free, closest, file.Doc)...)...)
+
+	prevEnd := file.Name.End()
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			free, closest := m.groupsBefore(prevEnd, decl.Pos(), decl.Doc)
+			m.pkg = append(m.pkg, markersIn(free...)...)
+			if decl.Tok == token.TYPE && !decl.Lparen.IsValid() {
+				// type Foo struct{...}: the doc comment is the one of the GenDecl
+				m.addType(decl.Specs[0].(*ast.TypeSpec), closest, decl.Doc)
+				break
+			}
+			// Markers before imports, variables, constants and type ( ... )
+			// blocks describe the package
+			m.pkg = append(m.pkg, markersIn(closest)...)
+			if decl.Tok == token.TYPE {
+				prevSpecEnd := decl.Lparen
+				for _, spec := range decl.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					_, closest := m.groupsBefore(prevSpecEnd, typeSpec.Pos(), typeSpec.Doc)
+					m.addType(typeSpec, closest, typeSpec.Doc)
+					prevSpecEnd = typeSpec.End()
+				}
+			}
+		case *ast.FuncDecl:
+			free, closest := m.groupsBefore(prevEnd, decl.Pos(), decl.Doc)
+			m.pkg = append(m.pkg, markersIn(append(free, closest)...)...)
+		}
+		prevEnd = decl.End()
+	}
+
+	// Markers after the last declaration describe the package too
+	free, closest = m.groupsBefore(prevEnd, token.NoPos, nil)
+	m.pkg = append(m.pkg, markersIn(append(free, closest)...)...)
+
+	return m
+}
+
+// addType records the markers of a type and of its fields
+func (m *fileMarkers) addType(typeSpec *ast.TypeSpec, closest, doc *ast.CommentGroup) {
+	m.nodes[typeSpec] = markersIn(closest, doc)
+
+	structType, ok := typeSpec.Type.(*ast.StructType)
+	if !ok || structType.Fields == nil {
+		return
+	}
+	prevEnd := structType.Fields.Opening
+	for _, field := range structType.Fields.List {
+		// Comment groups further away than the closest one are ignored
+		_, closest := m.groupsBefore(prevEnd, field.Pos(), field.Doc)
+		m.nodes[field] = markersIn(closest, field.Doc)
+		prevEnd = field.End()
+	}
+}
+
+// groupsBefore returns the comment groups after prevEnd and before pos,
+// leaving out doc, the doc comment of the node at pos. closest is the last of
+// them and free are the others. Comments on the line of prevEnd belong to the
+// previous node. An invalid pos stands for the end of the file.
+func (m *fileMarkers) groupsBefore(prevEnd, pos token.Pos, doc *ast.CommentGroup) (free []*ast.CommentGroup, closest *ast.CommentGroup) {
+	var groups []*ast.CommentGroup
+	for _, group := range m.comments {
+		if group == doc {
+			continue
+		}
+		if prevEnd.IsValid() && (group.Pos() < prevEnd || m.line(group.Pos()) == m.line(prevEnd)) {
+			continue
+		}
+		if pos.IsValid() && group.End() > pos {
+			break
+		}
+		groups = append(groups, group)
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	return groups[:len(groups)-1], groups[len(groups)-1]
+}
+
+func (m *fileMarkers) line(pos token.Pos) int {
+	return m.fset.Position(pos).Line
+}
+
+// markersIn returns the markers of the given comment groups, without their
+// leading +, in source order. Nil groups are skipped.
+func markersIn(groups ...*ast.CommentGroup) []string {
+	found := []string{}
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, "// +") {
+				found = append(found, strings.TrimSpace(strings.TrimPrefix(comment.Text, "// +")))
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+// TestTestTypesMarkers checks every marker of api/v1/test_types.go. Markers
+// used to be attached by their distance in lines to a declaration, which lost
+// markers of fields with more than two of them, missed markers separated from
+// a type by a long doc comment, and leaked markers into the next field.
+func TestTestTypesMarkers(t *testing.T) {
+	structs := loadStructs(t, "api/v1/test_types.go")
+
+	got := map[string][]string{}
+	for _, structInfo := range structs {
+		got[structInfo.Name] = structInfo.Markers
+		for _, field := range structInfo.Fields {
+			got[structInfo.Name+"."+field.Name] = field.Markers
+		}
+	}
+
+	want := map[string][]string{
+		"TestResource": {
+			"kubebuilder:object:root=true",
+			"kubebuilder:subresource:status",
+			"kubebuilder:resource:scope=Cluster",
+			"kubebuilder:printcolumn:name=Age,type=date,JSONPath=.metadata.creationTimestamp",
+			"kubebuilder:printcolumn:name=Status,type=string,JSONPath=.status.phase",
+		},
+		"TestResource.Spec":   {"required", "kubebuilder:validation:Required"},
+		"TestResource.Status": {"optional", "kubebuilder:validation:Optional"},
+		"TestResourceSpec":    {},
+		"TestResourceSpec.Name": {
+			"required",
+			"kubebuilder:validation:Required",
+			"kubebuilder:validation:MinLength=1",
+		},
+		"TestResourceSpec.Description": {
+			"optional",
+			"kubebuilder:validation:Optional",
+			`kubebuilder:default="Default description"`,
+		},
+		"TestResourceSpec.Replicas": {
+			"kubebuilder:validation:Minimum=1",
+			"kubebuilder:validation:Maximum=10",
+			"kubebuilder:default=1",
+		},
+		"TestResourceSpec.Tags": {"optional", "listType=set"},
+		"TestResourceStatus":    {},
+		"TestResourceStatus.Phase": {
+			"optional",
+			"kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed",
+		},
+		"TestResourceStatus.Conditions": {"optional", "listType=map", "listMapKey=type"},
+		"TestResourceStatus.Message":    {"optional"},
+		"TestResourceStatus.Reason":     {},
+		"TestResourceList":              {"kubebuilder:object:root=true"},
+		"TestResourceList.Items":        {},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		for name, markers := range want {
+			if !reflect.DeepEqual(got[name], markers) {
+				t.Errorf("Expected %s to have markers %q, got %q", name, markers, got[name])
+			}
+		}
+		for name, markers := range got {
+			if _, ok := want[name]; !ok {
+				t.Errorf("Unexpected %s with markers %q", name, markers)
+			}
+		}
+	}
+}
+
+func TestAssociateMarkers(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		wantPkg    []string
+		wantByName map[string][]string
+	}{
+		{
+			name: "package doc comment",
+			src: `// Package v1 is an API
+// +groupName=example.com
+package v1
+
+// +kubebuilder:object:root=true
+type Foo struct{}
+`,
+			wantPkg:    []string{"groupName=example.com"},
+			wantByName: map[string][]string{"Foo": {"kubebuilder:object:root=true"}},
+		},
+		{
+			name: "blank line between markers and doc comment",
+			src: `package v1
+
+// +kubebuilder:object:root=true
+
+// Foo is a type
+type Foo struct{}
+`,
+			wantPkg:    []string{},
+			wantByName: map[string][]string{"Foo": {"kubebuilder:object:root=true"}},
+		},
+		{
+			name: "free-standing markers describe the package",
+			src: `package v1
+
+// +kubebuilder:object:generate=true
+
+// +groupName=example.com
+
+// +kubebuilder:object:root=true
+
+// Foo is a type
+type Foo struct{}
+
+// +kubebuilder:skip
+`,
+			wantPkg:    []string{"kubebuilder:object:generate=true", "groupName=example.com", "kubebuilder:skip"},
+			wantByName: map[string][]string{"Foo": {"kubebuilder:object:root=true"}},
+		},
+		{
+			name: "markers before other declarations describe the package",
+			src: `package v1
+
+// +groupName=example.com
+
+import "fmt"
+
+// +kubebuilder:object:generate=false
+
+// The doc comments of other declarations are ignored
+// +kubebuilder:object:root=true
+var _ = fmt.Sprint
+
+// Foo is a type
+type Foo struct{}
+`,
+			wantPkg:    []string{"groupName=example.com", "kubebuilder:object:generate=false"},
+			wantByName: map[string][]string{"Foo": {}},
+		},
+		{
+			name: "type block",
+			src: `package v1
+
+// +groupName=example.com
+
+type (
+	// +kubebuilder:object:root=true
+	Foo struct{}
+
+	// +kubebuilder:object:root=true
+
+	// Bar is a type
+	Bar struct{}
+	Baz struct{}
+)
+`,
+			wantPkg: []string{"groupName=example.com"},
+			wantByName: map[string][]string{
+				"Foo": {"kubebuilder:object:root=true"},
+				"Bar": {"kubebuilder:object:root=true"},
+				"Baz": {},
+			},
+		},
+		{
+			name: "fields ignore all but the closest comment group",
+			src: `package v1
+
+type Foo struct {
+	// +optional
+	A string
+	B string // +optional
+
+	// +kubebuilder:validation:MaxLength=5
+
+	// +kubebuilder:validation:MinLength=1
+
+	// +optional
+	// C is a field
+	// +kubebuilder:default=c
+	C string
+	D string
+}
+`,
+			wantPkg: []string{},
+			wantByName: map[string][]string{
+				"Foo":   {},
+				"Foo.A": {"optional"},
+				"Foo.B": {},
+				"Foo.C": {"kubebuilder:validation:MinLength=1", "optional", "kubebuilder:default=c"},
+				"Foo.D": {},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "test.go", tt.src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			markers := associateMarkers(fset, file)
+
+			if !reflect.DeepEqual(markers.pkg, tt.wantPkg) {
+				t.Errorf("Expected package markers %q, got %q", tt.wantPkg, markers.pkg)
+			}
+
+			got := map[string][]string{}
+			for _, structInfo := range parseFile(fset, file, markers, "v1", "test.go") {
+				got[structInfo.Name] = structInfo.Markers
+				for _, field := range structInfo.Fields {
+					got[structInfo.Name+"."+field.Name] = field.Markers
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantByName) {
+				t.Errorf("Expected markers %q, got %q", tt.wantByName, got)
+			}
+		})
+	}
+}
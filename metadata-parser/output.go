@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// printJSON writes result as indented JSON
+func printJSON(w io.Writer, result Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// printYAML writes result as YAML, using the same field names as printJSON
+func printYAML(w io.Writer, result Result) error {
+	data, err := yaml.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// printText writes a human readable summary of result, grouped by package
+func printText(w io.Writer, args []string, result Result) {
+	fmt.Fprintf(w, "🔍 Parsing kubebuilder metadata from: %s\n\n", strings.Join(args, " "))
+
+	for i, pkg := range result.Packages {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		var structs []StructInfo
+		for _, structInfo := range result.Structs {
+			if structInfo.Package == pkg.Path {
+				structs = append(structs, structInfo)
+			}
+		}
+		printPackageText(w, pkg, structs)
+	}
+
+	fmt.Fprintf(w, "\n✅ Parsing completed successfully!\n")
+}
+
+// printPackageText writes the markers of pkg and of its structs
+func printPackageText(w io.Writer, pkg PackageInfo, structs []StructInfo) {
+	fmt.Fprintf(w, "📦 Package: %s\n", pkg.Path)
+	if len(pkg.Markers) > 0 {
+		fmt.Fprintf(w, "   🏷️  Package-level markers (%d):\n", len(pkg.Markers))
+		for _, marker := range pkg.Markers {
+			fmt.Fprintf(w, "      • %s\n", marker)
+		}
+	} else {
+		fmt.Fprintf(w, "   🏷️  Package-level markers: (none)\n")
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "📊 Found %d struct(s) with kubebuilder metadata:\n\n", len(structs))
+
+	for i, structInfo := range structs {
+		fmt.Fprintf(w, "🏗️  Struct: %s\n", structInfo.Name)
+
+		if len(structInfo.Markers) > 0 {
+			fmt.Fprintf(w, "   📋 Struct-level markers (%d):\n", len(structInfo.Markers))
+			for _, marker := range structInfo.Markers {
+				fmt.Fprintf(w, "      • %s\n", marker)
+			}
+		} else {
+			fmt.Fprintf(w, "   📋 Struct-level markers: (none)\n")
+		}
+
+		fieldsWithMarkers := 0
+		for _, field := range structInfo.Fields {
+			if len(field.Markers) > 0 {
+				fieldsWithMarkers++
+			}
+		}
+
+		if len(structInfo.Fields) > 0 {
+			fmt.Fprintf(w, "   🔧 Field-level markers (%d fields, %d with markers):\n", len(structInfo.Fields), fieldsWithMarkers)
+			for _, field := range structInfo.Fields {
+				if len(field.Markers) > 0 {
+					fmt.Fprintf(w, "      Field: %s\n", field.Name)
+					for _, marker := range field.Markers {
+						fmt.Fprintf(w, "         • %s\n", marker)
+					}
+				}
+			}
+		} else {
+			fmt.Fprintf(w, "   🔧 Field-level markers: (no fields)\n")
+		}
+
+		if i < len(structs)-1 {
+			fmt.Fprintln(w, "   "+strings.Repeat("-", 50))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// load collects the packages and structs named by args. An argument is one of
+//   - a Go file, whose package markers and structs are reported
+//   - a directory, e.g. api/v1
+//   - a package pattern understood by go list, e.g. ./... or metadata-parser/api/v1
+//
+// Packages are loaded with go/packages, so the package markers of a file
+// such as groupversion_info.go apply to the types declared in other files.
+func load(args []string) (Result, error) {
+	result := Result{Packages: []PackageInfo{}, Structs: []StructInfo{}}
+
+	// Only the structs of the files given as arguments are reported, unless
+	// their package is also named by another argument
+	var patterns, packagePatterns []string
+	onlyFiles := map[string]bool{}
+	for _, arg := range args {
+		if strings.HasSuffix(arg, ".go") {
+			if _, err := os.Stat(arg); err != nil {
+				return result, err
+			}
+			abs, err := filepath.Abs(arg)
+			if err != nil {
+				return result, err
+			}
+			onlyFiles[abs] = true
+			patterns = append(patterns, "file="+arg)
+			continue
+		}
+		patterns = append(patterns, localPattern(arg))
+		packagePatterns = append(packagePatterns, localPattern(arg))
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return result, err
+	}
+
+	var errs []error
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			errs = append(errs, err)
+		}
+	})
+	if len(errs) > 0 {
+		return result, errors.Join(errs...)
+	}
+	if len(pkgs) == 0 {
+		return result, fmt.Errorf("no packages found")
+	}
+
+	wholePackages := map[string]bool{}
+	if len(onlyFiles) > 0 && len(packagePatterns) > 0 {
+		named, err := packages.Load(&packages.Config{Mode: packages.NeedName}, packagePatterns...)
+		if err != nil {
+			return result, err
+		}
+		for _, pkg := range named {
+			wholePackages[pkg.ID] = true
+		}
+	}
+
+	for _, pkg := range pkgs {
+		pkgInfo := PackageInfo{Path: pkg.PkgPath, Name: pkg.Name, Markers: []string{}}
+		for i, file := range pkg.Syntax {
+			markers := associateMarkers(pkg.Fset, file)
+			pkgInfo.Markers = append(pkgInfo.Markers, markers.pkg...)
+
+			filePath := pkg.CompiledGoFiles[i]
+			if len(onlyFiles) > 0 && !wholePackages[pkg.ID] && !onlyFiles[filePath] {
+				continue
+			}
+			result.Structs = append(result.Structs, parseFile(pkg.Fset, file, markers, pkg.PkgPath, displayPath(filePath))...)
+		}
+		result.Packages = append(result.Packages, pkgInfo)
+	}
+
+	return result, nil
+}
+
+// localPattern turns a directory such as api/v1 or api/... into ./api/v1 or
+// ./api/..., because go list reads it as an import path otherwise
+func localPattern(arg string) string {
+	if filepath.IsAbs(arg) || build.IsLocalImport(arg) {
+		return arg
+	}
+	if info, err := os.Stat(strings.TrimSuffix(arg, "/...")); err == nil && info.IsDir() {
+		return "./" + arg
+	}
+	return arg
+}
+
+// displayPath returns path relative to the working directory when it is
+// inside of it, so that the output does not depend on where the repo is
+// checked out
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
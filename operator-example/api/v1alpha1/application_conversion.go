@@ -0,0 +1,271 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+// ConversionDataAnnotation holds the v1beta1 spec of an Application served
+// as v1alpha1, so that fields v1alpha1 cannot represent (extra ports,
+// environment variables from sources, other resource names, the image and
+// drift policies) survive a round trip through v1alpha1.
+const ConversionDataAnnotation = "apps.example.com/conversion-data"
+
+// ConvertTo converts this Application to the Hub version (v1beta1).
+func (src *Application) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Application)
+
+	// Start from the data v1alpha1 could not represent, if any was saved
+	// when the object was converted from v1beta1
+	restored := v1beta1.ApplicationSpec{}
+	if data, ok := src.Annotations[ConversionDataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &restored); err != nil {
+			return fmt.Errorf("decoding %s annotation: %w", ConversionDataAnnotation, err)
+		}
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if dst.Annotations != nil {
+		delete(dst.Annotations, ConversionDataAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	dst.Spec.Image = src.Spec.Image
+	dst.Spec.Replicas = src.Spec.Replicas
+	dst.Spec.Ports = convertPortTo(src.Spec.Port, restored.Ports)
+	resources, err := convertResourcesTo(src.Spec.Resources, restored.Resources)
+	if err != nil {
+		return err
+	}
+	dst.Spec.Resources = resources
+	dst.Spec.Env = convertEnvTo(src.Spec.Env, restored.Env)
+	dst.Spec.PodLabels = src.Spec.PodLabels
+	dst.Spec.PodAnnotations = src.Spec.PodAnnotations
+	dst.Spec.ServiceAnnotations = src.Spec.ServiceAnnotations
+	dst.Spec.DependsOn = nil
+	for _, ref := range src.Spec.DependsOn {
+		dst.Spec.DependsOn = append(dst.Spec.DependsOn, v1beta1.ApplicationReference(ref))
+	}
+	dst.Spec.ImagePolicy = restored.ImagePolicy
+	// v1alpha1 has no drift policy, objects created through it get the default
+	dst.Spec.DriftPolicy = restored.DriftPolicy
+	if dst.Spec.DriftPolicy == "" {
+		dst.Spec.DriftPolicy = v1beta1.DriftPolicyRevert
+	}
+
+	dst.Status = v1beta1.ApplicationStatus{
+		Replicas:          src.Status.Replicas,
+		Selector:          src.Status.Selector,
+		ResolvedImage:     src.Status.ResolvedImage,
+		ResolvedDigest:    src.Status.ResolvedDigest,
+		AvailableReplicas: src.Status.AvailableReplicas,
+		ReadyReplicas:     src.Status.ReadyReplicas,
+		UpdatedReplicas:   src.Status.UpdatedReplicas,
+		Conditions:        src.Status.Conditions,
+		LastUpdateTime:    src.Status.LastUpdateTime,
+	}
+	for _, field := range src.Status.Drift {
+		dst.Status.Drift = append(dst.Status.Drift, v1beta1.DriftedField(field))
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *Application) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Application)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec.Image = src.Spec.Image
+	dst.Spec.Replicas = src.Spec.Replicas
+	dst.Spec.Port = 0
+	if len(src.Spec.Ports) > 0 {
+		dst.Spec.Port = src.Spec.Ports[0].ContainerPort
+	}
+	dst.Spec.Resources = ResourceRequirements{
+		CPURequest:    quantityString(src.Spec.Resources.Requests, corev1.ResourceCPU),
+		MemoryRequest: quantityString(src.Spec.Resources.Requests, corev1.ResourceMemory),
+		CPULimit:      quantityString(src.Spec.Resources.Limits, corev1.ResourceCPU),
+		MemoryLimit:   quantityString(src.Spec.Resources.Limits, corev1.ResourceMemory),
+	}
+	dst.Spec.Env = nil
+	for _, env := range src.Spec.Env {
+		dst.Spec.Env = append(dst.Spec.Env, EnvVar{Name: env.Name, Value: env.Value})
+	}
+	dst.Spec.PodLabels = src.Spec.PodLabels
+	dst.Spec.PodAnnotations = src.Spec.PodAnnotations
+	dst.Spec.ServiceAnnotations = src.Spec.ServiceAnnotations
+	dst.Spec.DependsOn = nil
+	for _, ref := range src.Spec.DependsOn {
+		dst.Spec.DependsOn = append(dst.Spec.DependsOn, ApplicationReference(ref))
+	}
+
+	dst.Status = ApplicationStatus{
+		Replicas:          src.Status.Replicas,
+		Selector:          src.Status.Selector,
+		ResolvedImage:     src.Status.ResolvedImage,
+		ResolvedDigest:    src.Status.ResolvedDigest,
+		AvailableReplicas: src.Status.AvailableReplicas,
+		ReadyReplicas:     src.Status.ReadyReplicas,
+		UpdatedReplicas:   src.Status.UpdatedReplicas,
+		Conditions:        src.Status.Conditions,
+		LastUpdateTime:    src.Status.LastUpdateTime,
+	}
+	for _, field := range src.Status.Drift {
+		dst.Status.Drift = append(dst.Status.Drift, DriftedField(field))
+	}
+
+	// Keep the full v1beta1 spec for the way back, but only when v1alpha1
+	// actually loses something to keep the annotation off simple objects
+	if lossy(src) {
+		data, err := json.Marshal(src.Spec)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[ConversionDataAnnotation] = string(data)
+	}
+	return nil
+}
+
+// convertPortTo turns the single v1alpha1 port into the v1beta1 port list.
+// Additional ports and the name and protocol of the first port are taken
+// from restored when available.
+func convertPortTo(port int32, restored []v1beta1.ApplicationPort) []v1beta1.ApplicationPort {
+	if port == 0 {
+		return nil
+	}
+	if len(restored) == 0 {
+		return []v1beta1.ApplicationPort{{Name: "http", ContainerPort: port, Protocol: corev1.ProtocolTCP}}
+	}
+	ports := append([]v1beta1.ApplicationPort{}, restored...)
+	ports[0].ContainerPort = port
+	return ports
+}
+
+// convertResourcesTo parses the v1alpha1 resource strings. Resources other
+// than CPU and memory are taken from restored.
+func convertResourcesTo(src ResourceRequirements, restored corev1.ResourceRequirements) (corev1.ResourceRequirements, error) {
+	dst := corev1.ResourceRequirements{Claims: restored.Claims}
+	for _, r := range []struct {
+		list     *corev1.ResourceList
+		restored corev1.ResourceList
+		name     corev1.ResourceName
+		value    string
+	}{
+		{&dst.Requests, restored.Requests, corev1.ResourceCPU, src.CPURequest},
+		{&dst.Requests, restored.Requests, corev1.ResourceMemory, src.MemoryRequest},
+		{&dst.Limits, restored.Limits, corev1.ResourceCPU, src.CPULimit},
+		{&dst.Limits, restored.Limits, corev1.ResourceMemory, src.MemoryLimit},
+	} {
+		for name, quantity := range r.restored {
+			if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+				setQuantity(r.list, name, quantity)
+			}
+		}
+		if r.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(r.value)
+		if err != nil {
+			return dst, fmt.Errorf("invalid %s quantity %q: %w", r.name, r.value, err)
+		}
+		setQuantity(r.list, r.name, quantity)
+	}
+	return dst, nil
+}
+
+// convertEnvTo converts the v1alpha1 environment variables. Variables with an
+// empty value get their source back from restored when one was saved.
+func convertEnvTo(src []EnvVar, restored []corev1.EnvVar) []corev1.EnvVar {
+	var dst []corev1.EnvVar
+	for i, env := range src {
+		converted := corev1.EnvVar{Name: env.Name, Value: env.Value}
+		if env.Value == "" {
+			// Prefer the entry at the same position in case names are repeated
+			source := -1
+			if i < len(restored) && restored[i].Name == env.Name {
+				source = i
+			} else {
+				for j := range restored {
+					if restored[j].Name == env.Name {
+						source = j
+						break
+					}
+				}
+			}
+			if source >= 0 && restored[source].ValueFrom != nil {
+				converted.ValueFrom = restored[source].ValueFrom.DeepCopy()
+			}
+		}
+		dst = append(dst, converted)
+	}
+	return dst
+}
+
+// lossy reports whether converting app to v1alpha1 drops information.
+func lossy(app *v1beta1.Application) bool {
+	spec := app.Spec
+	if len(spec.Ports) > 1 || len(spec.Resources.Claims) > 0 || spec.ImagePolicy != nil {
+		return true
+	}
+	if spec.DriftPolicy != "" && spec.DriftPolicy != v1beta1.DriftPolicyRevert {
+		return true
+	}
+	if len(spec.Ports) == 1 && (spec.Ports[0].Name != "http" || spec.Ports[0].Protocol != corev1.ProtocolTCP) {
+		return true
+	}
+	for _, list := range []corev1.ResourceList{spec.Resources.Requests, spec.Resources.Limits} {
+		for name := range list {
+			if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+				return true
+			}
+		}
+	}
+	for _, env := range spec.Env {
+		if env.ValueFrom != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func setQuantity(list *corev1.ResourceList, name corev1.ResourceName, quantity resource.Quantity) {
+	if *list == nil {
+		*list = corev1.ResourceList{}
+	}
+	(*list)[name] = quantity
+}
+
+func quantityString(list corev1.ResourceList, name corev1.ResourceName) string {
+	if quantity, ok := list[name]; ok {
+		return quantity.String()
+	}
+	return ""
+}
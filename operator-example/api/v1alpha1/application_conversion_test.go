@@ -0,0 +1,229 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/utils/ptr"
+
+	"github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+const fuzzIterations = 1000
+
+// fuzzer returns a fuzzer that only produces objects the API server would
+// accept, as conversion is never asked to handle anything else.
+func fuzzer(seed int64) *fuzz.Fuzzer {
+	quantities := []string{"100m", "250m", "1", "2", "64Mi", "128Mi", "1Gi", "500M"}
+	names := []string{"http", "grpc", "metrics", "admin"}
+	return fuzz.NewWithSeed(seed).NilChance(0.2).NumElements(0, 3).Funcs(
+		func(q *resource.Quantity, c fuzz.Continue) {
+			*q = resource.MustParse(quantities[c.Intn(len(quantities))])
+		},
+		func(r *ResourceRequirements, c fuzz.Continue) {
+			pick := func() string {
+				if c.RandBool() {
+					return ""
+				}
+				return quantities[c.Intn(len(quantities))]
+			}
+			*r = ResourceRequirements{CPURequest: pick(), MemoryRequest: pick(), CPULimit: pick(), MemoryLimit: pick()}
+		},
+		func(s *ApplicationSpec, c fuzz.Continue) {
+			c.FuzzNoCustom(s)
+			s.Port = c.Int31n(65535) + 1
+		},
+		// The conversion webhook sets TypeMeta, ConvertTo and ConvertFrom don't
+		func(m *metav1.TypeMeta, c fuzz.Continue) {},
+		func(m *metav1.ObjectMeta, c fuzz.Continue) {
+			c.FuzzNoCustom(m)
+			delete(m.Annotations, ConversionDataAnnotation)
+		},
+		// The API server defaults the drift policy of v1beta1 objects
+		func(p *v1beta1.DriftPolicy, c fuzz.Continue) {
+			*p = []v1beta1.DriftPolicy{v1beta1.DriftPolicyRevert, v1beta1.DriftPolicyReport, v1beta1.DriftPolicyIgnore}[c.Intn(3)]
+		},
+		func(p *v1beta1.ApplicationPort, c fuzz.Continue) {
+			p.Name = names[c.Intn(len(names))]
+			p.ContainerPort = c.Int31n(65535) + 1
+			p.Protocol = []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP}[c.Intn(2)]
+		},
+		func(e *corev1.EnvVar, c fuzz.Continue) {
+			e.Name = c.RandString()
+			if c.RandBool() {
+				e.Value = c.RandString()
+				return
+			}
+			e.ValueFrom = &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: c.RandString()},
+				Key:                  c.RandString(),
+			}}
+		},
+		func(l *corev1.ResourceList, c fuzz.Continue) {
+			*l = corev1.ResourceList{}
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, "nvidia.com/gpu"} {
+				if c.RandBool() {
+					var q resource.Quantity
+					c.Fuzz(&q)
+					(*l)[name] = q
+				}
+			}
+		},
+	)
+}
+
+func TestApplicationConversionRoundTripFromSpoke(t *testing.T) {
+	for i := 0; i < fuzzIterations; i++ {
+		original := &Application{}
+		fuzzer(int64(i)).Fuzz(original)
+
+		hub := &v1beta1.Application{}
+		if err := original.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("seed %d: ConvertTo failed: %v", i, err)
+		}
+		roundTripped := &Application{}
+		if err := roundTripped.ConvertFrom(hub); err != nil {
+			t.Fatalf("seed %d: ConvertFrom failed: %v", i, err)
+		}
+
+		if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+			t.Fatalf("seed %d: v1alpha1 -> v1beta1 -> v1alpha1 is not lossless:\n%s", i, diff.ObjectReflectDiff(original, roundTripped))
+		}
+	}
+}
+
+func TestApplicationConversionRoundTripFromHub(t *testing.T) {
+	for i := 0; i < fuzzIterations; i++ {
+		original := &v1beta1.Application{}
+		fuzzer(int64(i)).Fuzz(original)
+
+		spoke := &Application{}
+		if err := spoke.ConvertFrom(original.DeepCopy()); err != nil {
+			t.Fatalf("seed %d: ConvertFrom failed: %v", i, err)
+		}
+		roundTripped := &v1beta1.Application{}
+		if err := spoke.ConvertTo(roundTripped); err != nil {
+			t.Fatalf("seed %d: ConvertTo failed: %v", i, err)
+		}
+
+		if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+			t.Fatalf("seed %d: v1beta1 -> v1alpha1 -> v1beta1 is not lossless:\n%s", i, diff.ObjectReflectDiff(original, roundTripped))
+		}
+	}
+}
+
+func TestApplicationConversionTo(t *testing.T) {
+	src := &Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-app", Namespace: "default"},
+		Spec: ApplicationSpec{
+			Image:    "nginx:latest",
+			Replicas: ptr.To[int32](3),
+			Port:     8080,
+			Resources: ResourceRequirements{
+				CPURequest:    "0.1",
+				MemoryRequest: "128Mi",
+				CPULimit:      "200m",
+			},
+			Env: []EnvVar{{Name: "LOG_LEVEL", Value: "info"}},
+		},
+	}
+
+	dst := &v1beta1.Application{}
+	if err := src.ConvertTo(dst); err != nil {
+		t.Fatalf("ConvertTo failed: %v", err)
+	}
+
+	want := v1beta1.ApplicationSpec{
+		Image:    "nginx:latest",
+		Replicas: ptr.To[int32](3),
+		Ports:    []v1beta1.ApplicationPort{{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP}},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("200m"),
+			},
+		},
+		Env:         []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}},
+		DriftPolicy: v1beta1.DriftPolicyRevert,
+	}
+	if !apiequality.Semantic.DeepEqual(want, dst.Spec) {
+		t.Errorf("unexpected v1beta1 spec:\n%s", diff.ObjectReflectDiff(want, dst.Spec))
+	}
+	if _, ok := dst.Annotations[ConversionDataAnnotation]; ok {
+		t.Errorf("conversion data annotation leaked into v1beta1")
+	}
+}
+
+func TestApplicationConversionRejectsInvalidQuantity(t *testing.T) {
+	src := &Application{Spec: ApplicationSpec{
+		Image:     "nginx:latest",
+		Port:      80,
+		Resources: ResourceRequirements{CPURequest: "a lot"},
+	}}
+	if err := src.ConvertTo(&v1beta1.Application{}); err == nil {
+		t.Fatalf("expected an error for an invalid quantity")
+	}
+}
+
+func TestApplicationConversionFromKeepsLossyFields(t *testing.T) {
+	src := &v1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-app", Namespace: "default"},
+		Spec: v1beta1.ApplicationSpec{
+			Image: "nginx:latest",
+			Ports: []v1beta1.ApplicationPort{
+				{Name: "http", ContainerPort: 80, Protocol: corev1.ProtocolTCP},
+				{Name: "metrics", ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+
+	dst := &Application{}
+	if err := dst.ConvertFrom(src); err != nil {
+		t.Fatalf("ConvertFrom failed: %v", err)
+	}
+	if dst.Spec.Port != 80 {
+		t.Errorf("expected the primary port 80, got %d", dst.Spec.Port)
+	}
+	if _, ok := dst.Annotations[ConversionDataAnnotation]; !ok {
+		t.Errorf("expected the %s annotation to keep the metrics port", ConversionDataAnnotation)
+	}
+
+	// A client editing the v1alpha1 port must not lose the metrics port
+	dst.Spec.Port = 8080
+	back := &v1beta1.Application{}
+	if err := dst.ConvertTo(back); err != nil {
+		t.Fatalf("ConvertTo failed: %v", err)
+	}
+	want := []v1beta1.ApplicationPort{
+		{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+		{Name: "metrics", ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+	}
+	if got := back.Spec.Ports; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected ports %v, got %v", want, got)
+	}
+}
@@ -29,10 +29,11 @@ type ApplicationSpec struct {
 	// +kubebuilder:validation:Required
 	Image string `json:"image"`
 
-	// Replicas is the number of desired pods
-	// +kubebuilder:validation:Minimum=1
+	// Replicas is the number of desired pods. Zero scales the Application
+	// down without deleting it.
+	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:default=1
-	Replicas int32 `json:"replicas,omitempty"`
+	Replicas *int32 `json:"replicas,omitempty"`
 
 	// Port is the port that the application listens on
 	// +kubebuilder:validation:Minimum=1
@@ -45,6 +46,30 @@ type ApplicationSpec struct {
 
 	// Env is a list of environment variables to set in the container
 	Env []EnvVar `json:"env,omitempty"`
+
+	// PodLabels are extra labels added to the pods of the application.
+	// They cannot override the labels used by the Deployment selector.
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// PodAnnotations are extra annotations added to the pods of the application
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// ServiceAnnotations are extra annotations added to the Service
+	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
+
+	// DependsOn lists Applications that must be Available before this one
+	// is rolled out
+	DependsOn []ApplicationReference `json:"dependsOn,omitempty"`
+}
+
+// ApplicationReference refers to another Application
+type ApplicationReference struct {
+	// Name of the Application
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace of the Application, defaults to the namespace of the referring Application
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // ResourceRequirements describes the compute resource requirements
@@ -76,8 +101,40 @@ type EnvVar struct {
 	Value string `json:"value,omitempty"`
 }
 
+// DriftedField is a field of an owned object that differs from the desired state
+type DriftedField struct {
+	// Kind of the owned object
+	Kind string `json:"kind"`
+
+	// Name of the owned object
+	Name string `json:"name"`
+
+	// Path of the field, e.g. spec.template.spec.containers[0].image
+	Path string `json:"path"`
+
+	// Desired value of the field
+	Desired string `json:"desired,omitempty"`
+
+	// Actual value of the field
+	Actual string `json:"actual,omitempty"`
+}
+
 // ApplicationStatus defines the observed state of Application
 type ApplicationStatus struct {
+	// Replicas is the number of pods targeted by the Deployment of the application
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Selector is the label selector of the application pods in string form,
+	// used by the scale subresource for autoscalers
+	Selector string `json:"selector,omitempty"`
+
+	// ResolvedImage is the image selected by the image policy of the
+	// v1beta1 API, pinned by digest
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+
+	// ResolvedDigest is the digest of ResolvedImage
+	ResolvedDigest string `json:"resolvedDigest,omitempty"`
+
 	// AvailableReplicas is the number of available replicas
 	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
 
@@ -87,6 +144,10 @@ type ApplicationStatus struct {
 	// UpdatedReplicas is the number of updated replicas
 	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
 
+	// Drift lists the fields of the owned objects that were changed outside
+	// of the operator and are kept because of the drift policy
+	Drift []DriftedField `json:"drift,omitempty"`
+
 	// Conditions represent the latest available observations of the application's current state
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
@@ -94,8 +155,26 @@ type ApplicationStatus struct {
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
+const (
+	// AdoptAnnotation opts an Application in to adopting a pre-existing
+	// Deployment or Service of the same name that has no controller owner.
+	AdoptAnnotation = "apps.example.com/adopt"
+
+	// ConditionConflict is set to True when an object the Application would
+	// manage already exists and cannot be adopted.
+	ConditionConflict = "Conflict"
+
+	// ConditionAvailable is set to True when all desired replicas are available.
+	ConditionAvailable = "Available"
+
+	// ConditionWaitingForDependencies is set to True while an Application in
+	// DependsOn is missing, not Available or part of a dependency cycle.
+	ConditionWaitingForDependencies = "WaitingForDependencies"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 //+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
 //+kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.replicas"
 //+kubebuilder:printcolumn:name="Available",type="integer",JSONPath=".status.availableReplicas"
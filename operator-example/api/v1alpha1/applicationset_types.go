@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplicationSetLabel is set on every Application generated by an
+// ApplicationSet and holds the name of that ApplicationSet.
+const ApplicationSetLabel = "apps.example.com/applicationset"
+
+// ShardLabel assigns Applications and ApplicationSets to the operator
+// instance started with the same --shard. Generated Applications inherit it
+// from their ApplicationSet.
+const ShardLabel = "apps.example.com/shard"
+
+// ApplicationSetSpec defines the desired state of ApplicationSet
+type ApplicationSetSpec struct {
+	// Template is stamped out once for every target produced by the generators
+	// +kubebuilder:validation:Required
+	Template ApplicationTemplate `json:"template"`
+
+	// Generators produce the namespaces (and optionally names) of the
+	// Applications to create. Targets from all generators are combined.
+	// +kubebuilder:validation:MinItems=1
+	Generators []ApplicationSetGenerator `json:"generators"`
+}
+
+// ApplicationTemplate describes the Applications generated by an ApplicationSet.
+// The spec is a v1alpha1 ApplicationSpec, so generated Applications have a
+// single port and cannot use v1beta1 only fields such as imagePolicy or
+// driftPolicy.
+type ApplicationTemplate struct {
+	// Labels added to every generated Application
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations added to every generated Application
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Spec of every generated Application before per-target overrides are applied
+	// +kubebuilder:validation:Required
+	Spec ApplicationSpec `json:"spec"`
+}
+
+// ApplicationSetGenerator produces targets for an ApplicationSet.
+// Exactly one of its fields should be set.
+type ApplicationSetGenerator struct {
+	// List produces one target per element
+	List *ListGenerator `json:"list,omitempty"`
+
+	// Namespaces produces one target per namespace matching a label selector
+	Namespaces *NamespaceGenerator `json:"namespaces,omitempty"`
+}
+
+// ListGenerator produces a static list of targets
+type ListGenerator struct {
+	// Elements is the list of targets
+	Elements []ApplicationSetTarget `json:"elements"`
+}
+
+// NamespaceGenerator produces one target per namespace matching Selector
+type NamespaceGenerator struct {
+	// Selector selects the namespaces to create Applications in
+	// +kubebuilder:validation:Required
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// Overrides are applied to the Application in every selected namespace
+	Overrides *ApplicationOverrides `json:"overrides,omitempty"`
+}
+
+// ApplicationSetTarget is a single Application produced by an ApplicationSet
+type ApplicationSetTarget struct {
+	// Namespace to create the Application in
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+
+	// Name of the Application, defaults to the name of the ApplicationSet
+	Name string `json:"name,omitempty"`
+
+	// Overrides are applied to the template for this target only
+	Overrides *ApplicationOverrides `json:"overrides,omitempty"`
+}
+
+// ApplicationOverrides replaces parts of the template spec for a target
+type ApplicationOverrides struct {
+	// Image replaces the template image
+	Image string `json:"image,omitempty"`
+
+	// Replicas replaces the template replica count
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources replaces the template resources
+	Resources *ResourceRequirements `json:"resources,omitempty"`
+
+	// Env entries replace template entries with the same name, other entries are appended
+	Env []EnvVar `json:"env,omitempty"`
+
+	// PodLabels are merged into the template pod labels
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+}
+
+// ApplicationSetStatus defines the observed state of ApplicationSet
+type ApplicationSetStatus struct {
+	// Applications lists the Applications currently generated by this ApplicationSet
+	Applications []ApplicationReference `json:"applications,omitempty"`
+
+	// Conditions represent the latest available observations of the ApplicationSet's current state
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.template.spec.image"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ApplicationSet is the Schema for the applicationsets API. It is cluster
+// scoped so that it can own Applications in any namespace.
+type ApplicationSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ApplicationSetSpec   `json:"spec,omitempty"`
+	Status ApplicationSetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ApplicationSetList contains a list of ApplicationSet
+type ApplicationSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ApplicationSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ApplicationSet{}, &ApplicationSetList{})
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplicationSpec defines the desired state of Application
+type ApplicationSpec struct {
+	// Image is the container image to run
+	// +kubebuilder:validation:Required
+	Image string `json:"image"`
+
+	// Replicas is the number of desired pods. Zero scales the Application
+	// down without deleting it.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Ports are the ports that the application listens on. The first port
+	// is the primary port of the application.
+	// +kubebuilder:default={{name: "http", containerPort: 80}}
+	// +listType=map
+	// +listMapKey=name
+	Ports []ApplicationPort `json:"ports,omitempty"`
+
+	// Resources defines the compute resources required
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Env is a list of environment variables to set in the container
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// PodLabels are extra labels added to the pods of the application.
+	// They cannot override the labels used by the Deployment selector.
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// PodAnnotations are extra annotations added to the pods of the application
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// ServiceAnnotations are extra annotations added to the Service
+	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
+
+	// DependsOn lists Applications that must be Available before this one
+	// is rolled out
+	DependsOn []ApplicationReference `json:"dependsOn,omitempty"`
+
+	// ImagePolicy keeps the image up to date with the newest matching tag in
+	// the registry. The resolved tag is pinned by digest in the Deployment.
+	ImagePolicy *ImagePolicy `json:"imagePolicy,omitempty"`
+
+	// DriftPolicy decides what happens when the owned Deployment or Service
+	// is changed outside of the operator, e.g. with kubectl edit
+	// +kubebuilder:validation:Enum=Revert;Report;Ignore
+	// +kubebuilder:default=Revert
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+}
+
+// DriftPolicy decides how drift of owned objects is handled
+type DriftPolicy string
+
+const (
+	// DriftPolicyRevert restores the desired state and reports nothing
+	DriftPolicyRevert DriftPolicy = "Revert"
+
+	// DriftPolicyReport leaves the changes in place and lists them in status.drift
+	DriftPolicyReport DriftPolicy = "Report"
+
+	// DriftPolicyIgnore leaves the changes in place without reporting them
+	DriftPolicyIgnore DriftPolicy = "Ignore"
+)
+
+// ImagePolicy selects the image tag to run from the tags of a repository
+// +kubebuilder:validation:XValidation:rule="has(self.semver) != has(self.tagPattern)",message="exactly one of semver and tagPattern must be set"
+type ImagePolicy struct {
+	// Repository to scan for tags, defaults to the repository of spec.image
+	Repository string `json:"repository,omitempty"`
+
+	// Semver selects the highest tag within a semantic version range,
+	// e.g. ">=1.2.0 <2.0.0" or "~1.4"
+	Semver string `json:"semver,omitempty"`
+
+	// TagPattern selects the greatest tag in lexical order matching a
+	// regular expression, e.g. "^main-[0-9]{14}$"
+	TagPattern string `json:"tagPattern,omitempty"`
+
+	// Interval between two registry scans
+	// +kubebuilder:default="5m"
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// ApplicationPort is a port exposed by the application container and its Service
+type ApplicationPort struct {
+	// Name of the port, used for both the container port and the Service port
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=15
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// ContainerPort is the port number the application listens on
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ContainerPort int32 `json:"containerPort"`
+
+	// Protocol of the port
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+}
+
+// ApplicationReference refers to another Application
+type ApplicationReference struct {
+	// Name of the Application
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace of the Application, defaults to the namespace of the referring Application
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// DriftedField is a field of an owned object that differs from the desired state
+type DriftedField struct {
+	// Kind of the owned object
+	Kind string `json:"kind"`
+
+	// Name of the owned object
+	Name string `json:"name"`
+
+	// Path of the field, e.g. spec.template.spec.containers[0].image
+	Path string `json:"path"`
+
+	// Desired value of the field
+	Desired string `json:"desired,omitempty"`
+
+	// Actual value of the field
+	Actual string `json:"actual,omitempty"`
+}
+
+// ApplicationStatus defines the observed state of Application
+type ApplicationStatus struct {
+	// Replicas is the number of pods targeted by the Deployment of the application
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Selector is the label selector of the application pods in string form,
+	// used by the scale subresource for autoscalers
+	Selector string `json:"selector,omitempty"`
+
+	// ResolvedImage is the image selected by spec.imagePolicy, pinned by digest
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+
+	// ResolvedDigest is the digest of ResolvedImage
+	ResolvedDigest string `json:"resolvedDigest,omitempty"`
+
+	// AvailableReplicas is the number of available replicas
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+
+	// ReadyReplicas is the number of ready replicas
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// UpdatedReplicas is the number of updated replicas
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+
+	// Drift lists the fields of the owned objects that were changed outside
+	// of the operator and are kept because of the drift policy
+	Drift []DriftedField `json:"drift,omitempty"`
+
+	// Conditions represent the latest available observations of the application's current state
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastUpdateTime is the last time the status was updated
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+const (
+	// AdoptAnnotation opts an Application in to adopting a pre-existing
+	// Deployment or Service of the same name that has no controller owner.
+	AdoptAnnotation = "apps.example.com/adopt"
+
+	// ConditionConflict is set to True when an object the Application would
+	// manage already exists and cannot be adopted.
+	ConditionConflict = "Conflict"
+
+	// ConditionAvailable is set to True when all desired replicas are available.
+	ConditionAvailable = "Available"
+
+	// ConditionWaitingForDependencies is set to True while an Application in
+	// DependsOn is missing, not Available or part of a dependency cycle.
+	ConditionWaitingForDependencies = "WaitingForDependencies"
+
+	// ConditionImageResolved is set to True when spec.imagePolicy resolved
+	// to an image digest.
+	ConditionImageResolved = "ImageResolved"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
+//+kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.replicas"
+//+kubebuilder:printcolumn:name="Available",type="integer",JSONPath=".status.availableReplicas"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Application is the Schema for the applications API
+type Application struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ApplicationSpec   `json:"spec,omitempty"`
+	Status ApplicationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ApplicationList contains a list of Application
+type ApplicationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Application `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Application{}, &ApplicationList{})
+}
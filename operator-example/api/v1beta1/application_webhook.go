@@ -0,0 +1,35 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// log is for logging in this package.
+var applicationlog = logf.Log.WithName("application-resource")
+
+// SetupWebhookWithManager registers the webhooks for Application. As
+// Application is a conversion hub this serves the /convert endpoint
+// used by the API server to convert between API versions.
+func (r *Application) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	applicationlog.Info("registering conversion webhook")
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
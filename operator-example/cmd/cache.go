@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+// shardLabel assigns Applications and ApplicationSets to the operator
+// instance started with the same --shard
+const shardLabel = appsv1alpha1.ShardLabel
+
+// cacheOptions restricts the manager cache to the namespaces in
+// watchNamespaces, a comma separated list, and the Applications and
+// ApplicationSets to those matching selector and belonging to shard.
+// Empty values do not restrict anything.
+func cacheOptions(watchNamespaces, selector, shard string) (cache.Options, error) {
+	opts := cache.Options{}
+
+	for _, namespace := range strings.Split(watchNamespaces, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			continue
+		}
+		if opts.DefaultNamespaces == nil {
+			opts.DefaultNamespaces = map[string]cache.Config{}
+		}
+		opts.DefaultNamespaces[namespace] = cache.Config{}
+	}
+
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return opts, fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+	if shard != "" {
+		if errs := validation.IsDNS1123Label(shard); len(errs) > 0 {
+			return opts, fmt.Errorf("invalid shard %q: %s", shard, strings.Join(errs, ", "))
+		}
+		requirement, err := labels.NewRequirement(shardLabel, selection.Equals, []string{shard})
+		if err != nil {
+			return opts, err
+		}
+		sel = sel.Add(*requirement)
+	}
+	if !sel.Empty() {
+		// The cache keeps one informer per version, the ApplicationSet
+		// controller reads v1alpha1 Applications
+		opts.ByObject = map[client.Object]cache.ByObject{
+			&appsv1beta1.Application{}:     {Label: sel},
+			&appsv1alpha1.Application{}:    {Label: sel},
+			&appsv1alpha1.ApplicationSet{}: {Label: sel},
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+func TestCacheOptions(t *testing.T) {
+	tests := []struct {
+		name            string
+		watchNamespaces string
+		selector        string
+		shard           string
+		namespaces      []string
+		matches         labels.Set
+		rejects         labels.Set
+		wantErr         bool
+	}{
+		{
+			name: "whole cluster",
+		},
+		{
+			name:            "namespaces",
+			watchNamespaces: "team-a, team-b,",
+			namespaces:      []string{"team-a", "team-b"},
+		},
+		{
+			name:     "selector",
+			selector: "team=payments",
+			matches:  labels.Set{"team": "payments"},
+			rejects:  labels.Set{"team": "search"},
+		},
+		{
+			name:     "selector and shard",
+			selector: "team=payments",
+			shard:    "eu-1",
+			matches:  labels.Set{"team": "payments", shardLabel: "eu-1"},
+			rejects:  labels.Set{"team": "payments", shardLabel: "eu-2"},
+		},
+		{
+			name:     "invalid selector",
+			selector: "team in",
+			wantErr:  true,
+		},
+		{
+			name:    "invalid shard",
+			shard:   "EU_1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := cacheOptions(tt.watchNamespaces, tt.selector, tt.shard)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var namespaces []string
+			for namespace := range opts.DefaultNamespaces {
+				namespaces = append(namespaces, namespace)
+			}
+			sort.Strings(namespaces)
+			if len(namespaces) != len(tt.namespaces) {
+				t.Fatalf("namespaces = %v, want %v", namespaces, tt.namespaces)
+			}
+			for i := range namespaces {
+				if namespaces[i] != tt.namespaces[i] {
+					t.Fatalf("namespaces = %v, want %v", namespaces, tt.namespaces)
+				}
+			}
+
+			if tt.matches == nil {
+				if opts.ByObject != nil {
+					t.Fatalf("unexpected per object options: %v", opts.ByObject)
+				}
+				return
+			}
+			for obj, byObject := range opts.ByObject {
+				if _, ok := obj.(*appsv1beta1.Application); !ok {
+					continue
+				}
+				if !byObject.Label.Matches(tt.matches) {
+					t.Errorf("selector %s does not match %v", byObject.Label, tt.matches)
+				}
+				if byObject.Label.Matches(tt.rejects) {
+					t.Errorf("selector %s matches %v", byObject.Label, tt.rejects)
+				}
+				return
+			}
+			t.Fatal("no options for Applications")
+		})
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liweinan/k8s-example/operator-example/internal/managerconfig"
+)
+
+// managerConfig returns the configuration in the file at path, or the
+// defaults when path is empty, as loaded and after overriding it with the
+// flags set on the command line.
+func managerConfig(path string, fs *flag.FlagSet) (loaded, effective *managerconfig.ManagerConfig, err error) {
+	loaded = managerconfig.Default()
+	if path != "" {
+		if loaded, err = managerconfig.Load(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cfg := *loaded
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		value := f.Value.String()
+		switch f.Name {
+		case "metrics-bind-address":
+			cfg.Metrics.BindAddress = value
+		case "metrics-secure":
+			cfg.Metrics.Secure, err = strconv.ParseBool(value)
+		case "health-probe-bind-address":
+			cfg.Health.ProbeBindAddress = value
+		case "leader-elect":
+			cfg.LeaderElection.LeaderElect, err = strconv.ParseBool(value)
+		case "watch-namespaces":
+			cfg.WatchNamespaces = nil
+			for _, namespace := range strings.Split(value, ",") {
+				if namespace = strings.TrimSpace(namespace); namespace != "" {
+					cfg.WatchNamespaces = append(cfg.WatchNamespaces, namespace)
+				}
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("--%s: %w", f.Name, err)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return loaded, &cfg, nil
+}
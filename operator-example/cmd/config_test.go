@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagerConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(`apiVersion: config.apps.example.com/v1alpha1
+kind: ManagerConfig
+metrics:
+  bindAddress: 127.0.0.1:8080
+leaderElection:
+  leaderElect: true
+watchNamespaces: [team-a]
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	newFlagSet := func() *flag.FlagSet {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String("metrics-bind-address", ":8080", "")
+		fs.String("health-probe-bind-address", ":8081", "")
+		fs.Bool("leader-elect", false, "")
+		fs.String("watch-namespaces", "", "")
+		return fs
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		args            []string
+		metricsAddr     string
+		leaderElect     bool
+		watchNamespaces []string
+		wantErr         bool
+	}{
+		{
+			name:        "defaults",
+			metricsAddr: ":8080",
+		},
+		{
+			name:            "flags only",
+			args:            []string{"--leader-elect", "--watch-namespaces=team-b,team-c"},
+			metricsAddr:     ":8080",
+			leaderElect:     true,
+			watchNamespaces: []string{"team-b", "team-c"},
+		},
+		{
+			name:            "file",
+			path:            path,
+			metricsAddr:     "127.0.0.1:8080",
+			leaderElect:     true,
+			watchNamespaces: []string{"team-a"},
+		},
+		{
+			name:        "flags override the file",
+			path:        path,
+			args:        []string{"--leader-elect=false", "--watch-namespaces=", "--metrics-bind-address=0"},
+			metricsAddr: "0",
+		},
+		{
+			name:    "invalid flag value",
+			args:    []string{"--watch-namespaces=Team_A"},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			path:    filepath.Join(t.TempDir(), "missing.yaml"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFlagSet()
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			_, cfg, err := managerConfig(tt.path, fs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Metrics.BindAddress != tt.metricsAddr {
+				t.Errorf("metrics address = %q, want %q", cfg.Metrics.BindAddress, tt.metricsAddr)
+			}
+			if cfg.LeaderElection.LeaderElect != tt.leaderElect {
+				t.Errorf("leader election = %v, want %v", cfg.LeaderElection.LeaderElect, tt.leaderElect)
+			}
+			if len(cfg.WatchNamespaces) != len(tt.watchNamespaces) {
+				t.Fatalf("watchNamespaces = %v, want %v", cfg.WatchNamespaces, tt.watchNamespaces)
+			}
+			for i := range cfg.WatchNamespaces {
+				if cfg.WatchNamespaces[i] != tt.watchNamespaces[i] {
+					t.Errorf("watchNamespaces = %v, want %v", cfg.WatchNamespaces, tt.watchNamespaces)
+				}
+			}
+		})
+	}
+}
@@ -20,9 +20,11 @@ import (
 	"crypto/tls"
 	"flag"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -30,8 +32,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -39,7 +39,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
 	"github.com/liweinan/k8s-example/operator-example/internal/controller"
+	"github.com/liweinan/k8s-example/operator-example/internal/managerconfig"
+	"github.com/liweinan/k8s-example/operator-example/pkg/kubeconfig"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -52,31 +55,25 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(appsv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
-func getKubeconfig() (*rest.Config, error) {
-	// Try to get config from k8s kubectl
-	cmd := exec.Command("k8s", "kubectl", "config", "view", "--raw")
-	output, err := cmd.CombinedOutput()
-	if err == nil {
-		// Create a temporary file with the config
-		tmpFile := filepath.Join(os.TempDir(), "kubeconfig")
-		if err := os.WriteFile(tmpFile, output, 0600); err == nil {
-			return clientcmd.BuildConfigFromFlags("", tmpFile)
-		}
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		os.Exit(runRender(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
 	}
 
-	// If all else fails, use the default kubeconfig
-	return clientcmd.BuildConfigFromFlags("", filepath.Join(os.Getenv("HOME"), ".kube", "config"))
-}
-
-func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var watchNamespaces string
+	var selector string
+	var shard string
+	var kubeContext string
+	var configFile string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -86,6 +83,17 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma separated list of namespaces to watch. All namespaces are watched if empty.")
+	flag.StringVar(&selector, "selector", "",
+		"Label selector restricting the Applications and ApplicationSets reconciled by this instance, e.g. team=payments")
+	flag.StringVar(&shard, "shard", "",
+		"Only reconcile Applications and ApplicationSets labelled "+shardLabel+"=<shard>, "+
+			"so that several instances can split them between each other")
+	flag.StringVar(&configFile, "config", "",
+		"Path of a "+managerconfig.Kind+" file. Flags set on the command line override its values.")
+	// --kubeconfig is registered by controller-runtime
+	flag.StringVar(&kubeContext, "context", "", "The kubeconfig context to use instead of the current context")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -94,6 +102,12 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	loadedConfig, cfg, err := managerConfig(configFile, flag.CommandLine)
+	if err != nil {
+		setupLog.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancelation and
@@ -114,24 +128,43 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
-	// Get kubeconfig
-	kubeconfig, err := getKubeconfig()
+	cacheOpts, err := cacheOptions(strings.Join(cfg.WatchNamespaces, ","), selector, shard)
+	if err != nil {
+		setupLog.Error(err, "unable to configure the cache")
+		os.Exit(1)
+	}
+
+	// Every shard elects its own leader
+	leaderElectionID := cfg.LeaderElection.ResourceName
+	if shard != "" {
+		leaderElectionID = shard + "." + leaderElectionID
+	}
+
+	restConfig, source, err := kubeconfig.Load(kubeconfig.Options{
+		Kubeconfig: flag.Lookup("kubeconfig").Value.String(),
+		Context:    kubeContext,
+	})
 	if err != nil {
-		setupLog.Error(err, "unable to get kubeconfig")
+		setupLog.Error(err, "unable to load the Kubernetes configuration")
 		os.Exit(1)
 	}
+	setupLog.Info("Using Kubernetes configuration", "source", source.String())
 
-	mgr, err := ctrl.NewManager(kubeconfig, ctrl.Options{
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme: scheme,
+		Cache:  cacheOpts,
 		Metrics: metricsserver.Options{
-			BindAddress:   metricsAddr,
-			SecureServing: secureMetrics,
+			BindAddress:   cfg.Metrics.BindAddress,
+			SecureServing: cfg.Metrics.Secure,
 			TLSOpts:       tlsOpts,
 		},
 		WebhookServer:          webhookServer,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "b84ea92d.example.com",
+		HealthProbeBindAddress: cfg.Health.ProbeBindAddress,
+		LeaderElection:         cfg.LeaderElection.LeaderElect,
+		LeaderElectionID:       leaderElectionID,
+		LeaseDuration:          &cfg.LeaderElection.LeaseDuration.Duration,
+		RenewDeadline:          &cfg.LeaderElection.RenewDeadline.Duration,
+		RetryPeriod:            &cfg.LeaderElection.RetryPeriod.Duration,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -149,13 +182,77 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Reload the configuration file for the fields that can change at runtime
+	requeueInterval := func() time.Duration { return cfg.Controller.RequeueInterval.Duration }
+	requeueJitter := func() float64 { return cfg.Controller.RequeueJitter }
+	if configFile != "" {
+		watcher, err := managerconfig.NewWatcher(configFile, loadedConfig, cfg)
+		if err != nil {
+			setupLog.Error(err, "unable to watch the configuration file")
+			os.Exit(1)
+		}
+		if err := mgr.Add(watcher); err != nil {
+			setupLog.Error(err, "unable to watch the configuration file")
+			os.Exit(1)
+		}
+		requeueInterval = watcher.RequeueInterval
+		requeueJitter = watcher.RequeueJitter
+	}
+
 	if err = (&controller.ApplicationReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		APIReader:               mgr.GetAPIReader(),
+		MaxConcurrentReconciles: cfg.Controller.MaxConcurrentReconciles,
+		RequeueInterval:         requeueInterval,
+		RequeueJitter:           requeueJitter,
+		RateLimiter: controller.NewRateLimiter(
+			cfg.Controller.RateLimiter.BaseDelay.Duration,
+			cfg.Controller.RateLimiter.MaxDelay.Duration,
+			cfg.Controller.RateLimiter.QPS,
+			cfg.Controller.RateLimiter.Burst,
+		),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Application")
 		os.Exit(1)
 	}
+	// ApplicationSets create Applications in any namespace, and the
+	// Applications they create only carry the shard of the ApplicationSet,
+	// not the labels matched by --selector
+	switch {
+	case !cfg.Enabled(managerconfig.ApplicationSet):
+		setupLog.Info("ApplicationSet controller disabled by feature gate")
+	case selector != "":
+		setupLog.Info("ApplicationSet controller disabled, it cannot be combined with --selector")
+	case cacheOpts.DefaultNamespaces == nil:
+		if err = (&controller.ApplicationSetReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ApplicationSet")
+			os.Exit(1)
+		}
+	default:
+		setupLog.Info("ApplicationSet controller disabled, it requires watching all namespaces")
+	}
+	if cfg.Enabled(managerconfig.ImagePolicy) {
+		if err = (&controller.ImagePolicyReconciler{
+			Client:          mgr.GetClient(),
+			Scheme:          mgr.GetScheme(),
+			RegistryOptions: []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)},
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ImagePolicy")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("ImagePolicy controller disabled by feature gate")
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&appsv1beta1.Application{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Application")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
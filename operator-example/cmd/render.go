@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+	"github.com/liweinan/k8s-example/operator-example/internal/controller"
+)
+
+// runRender implements the render subcommand: it reads Application manifests
+// and prints the objects the operator would create for them.
+func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var filename string
+	fs.StringVar(&filename, "f", "-", "File containing the Application manifests, - reads from stdin")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s render [-f FILE]\n\n", os.Args[0])
+		fmt.Fprintln(stderr, "Prints the objects the operator creates for the Applications in FILE.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	in := stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if err := render(in, stdout); err != nil {
+		fmt.Fprintln(stderr, "render:", err)
+		return 1
+	}
+	return 0
+}
+
+// render decodes every Application in the YAML or JSON stream in and writes
+// the rendered objects to out as a multi-document YAML stream.
+func render(in io.Reader, out io.Writer) error {
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+	first := true
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, gvk, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return err
+		}
+		var objects []client.Object
+		switch decoded := obj.(type) {
+		case *appsv1beta1.Application:
+			objects, err = controller.RenderApplication(scheme, decoded)
+		case *appsv1alpha1.Application:
+			var withResources bool
+			withResources, err = setsResources(doc)
+			if err == nil {
+				objects, err = controller.RenderV1alpha1Application(scheme, decoded, withResources)
+			}
+		default:
+			return fmt.Errorf("expected an Application, got %s", gvk.Kind)
+		}
+		if err != nil {
+			return err
+		}
+		for _, rendered := range objects {
+			data, err := manifest(rendered)
+			if err != nil {
+				return err
+			}
+			if !first {
+				fmt.Fprintln(out, "---")
+			}
+			first = false
+			if _, err := out.Write(data); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// setsResources reports whether the Application manifest in doc sets
+// spec.resources, which decides whether its fields are defaulted.
+func setsResources(doc []byte) (bool, error) {
+	var manifest struct {
+		Spec struct {
+			Resources json.RawMessage `json:"resources"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal(doc, &manifest); err != nil {
+		return false, err
+	}
+	resources := bytes.TrimSpace(manifest.Spec.Resources)
+	return len(resources) > 0 && string(resources) != "null", nil
+}
+
+// manifest returns obj as YAML without the fields only the API server
+// fills in, so that rendered manifests diff cleanly.
+func manifest(obj runtime.Object) ([]byte, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(content, "status")
+	unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(content, "spec", "template", "metadata", "creationTimestamp")
+	return yaml.Marshal(content)
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+const v1beta1Application = `apiVersion: apps.example.com/v1beta1
+kind: Application
+metadata:
+  name: web
+  namespace: shop
+spec:
+  image: nginx:1.25.3
+  replicas: 3
+  ports:
+  - name: http
+    containerPort: 8080
+`
+
+const v1alpha1Application = `apiVersion: apps.example.com/v1alpha1
+kind: Application
+metadata:
+  name: legacy
+spec:
+  image: busybox:1.36
+  port: 9090
+`
+
+// v1alpha1PartialApplication leaves the port and most resources to the CRD
+// defaults
+const v1alpha1PartialApplication = `apiVersion: apps.example.com/v1alpha1
+kind: Application
+metadata:
+  name: partial
+spec:
+  image: busybox:1.36
+  resources:
+    cpuRequest: 50m
+`
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		namespace string
+		appName   string
+		image     string
+		replicas  int32
+		port      int32
+		resources corev1.ResourceRequirements
+	}{
+		{
+			name:      "v1beta1",
+			input:     v1beta1Application,
+			namespace: "shop",
+			appName:   "web",
+			image:     "nginx:1.25.3",
+			replicas:  3,
+			port:      8080,
+		},
+		{
+			name:      "v1alpha1",
+			input:     v1alpha1Application,
+			namespace: "default",
+			appName:   "legacy",
+			image:     "busybox:1.36",
+			replicas:  1,
+			port:      9090,
+		},
+		{
+			name:      "v1alpha1 with defaults",
+			input:     v1alpha1PartialApplication,
+			namespace: "default",
+			appName:   "partial",
+			image:     "busybox:1.36",
+			replicas:  1,
+			port:      80,
+			resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("50m"),
+					corev1.ResourceMemory: resource.MustParse("128Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("200m"),
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := render(strings.NewReader(tt.input), &out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Contains(out.String(), "creationTimestamp") || strings.Contains(out.String(), "status:") {
+				t.Errorf("output contains fields filled in by the API server:\n%s", out.String())
+			}
+
+			docs := strings.Split(out.String(), "\n---\n")
+			if len(docs) != 2 {
+				t.Fatalf("got %d documents, want 2:\n%s", len(docs), out.String())
+			}
+
+			dep := &appsv1.Deployment{}
+			if err := yaml.UnmarshalStrict([]byte(docs[0]), dep); err != nil {
+				t.Fatalf("decoding Deployment: %v", err)
+			}
+			if dep.APIVersion != "apps/v1" || dep.Kind != "Deployment" {
+				t.Errorf("first document is %s %s, want apps/v1 Deployment", dep.APIVersion, dep.Kind)
+			}
+			if dep.Namespace != tt.namespace || dep.Name != tt.appName {
+				t.Errorf("Deployment is %s/%s, want %s/%s", dep.Namespace, dep.Name, tt.namespace, tt.appName)
+			}
+			if dep.Spec.Replicas == nil || *dep.Spec.Replicas != tt.replicas {
+				t.Errorf("replicas = %v, want %d", dep.Spec.Replicas, tt.replicas)
+			}
+			container := dep.Spec.Template.Spec.Containers[0]
+			if container.Image != tt.image {
+				t.Errorf("image = %q, want %q", container.Image, tt.image)
+			}
+			if len(container.Ports) != 1 || container.Ports[0].ContainerPort != tt.port {
+				t.Errorf("container ports = %v, want %d", container.Ports, tt.port)
+			}
+			if !apiequality.Semantic.DeepEqual(container.Resources, tt.resources) {
+				t.Errorf("resources = %v, want %v", container.Resources, tt.resources)
+			}
+			if len(dep.OwnerReferences) != 1 || dep.OwnerReferences[0].APIVersion != "apps.example.com/v1beta1" {
+				t.Errorf("owner references = %v, want the v1beta1 Application", dep.OwnerReferences)
+			}
+
+			svc := &corev1.Service{}
+			if err := yaml.UnmarshalStrict([]byte(docs[1]), svc); err != nil {
+				t.Fatalf("decoding Service: %v", err)
+			}
+			if svc.APIVersion != "v1" || svc.Kind != "Service" {
+				t.Errorf("second document is %s %s, want v1 Service", svc.APIVersion, svc.Kind)
+			}
+			if len(svc.Spec.Ports) != 1 || svc.Spec.Ports[0].Port != tt.port {
+				t.Errorf("service ports = %v, want %d", svc.Spec.Ports, tt.port)
+			}
+		})
+	}
+}
+
+func TestRenderStream(t *testing.T) {
+	var out bytes.Buffer
+	input := v1beta1Application + "---\n" + v1alpha1Application
+	if err := render(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Count(out.String(), "\n---\n"); got != 3 {
+		t.Errorf("got %d separators, want 3 for two Applications:\n%s", got, out.String())
+	}
+}
+
+func TestRenderRejectsOtherKinds(t *testing.T) {
+	input := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`
+	err := render(strings.NewReader(input), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "expected an Application, got ConfigMap") {
+		t.Fatalf("err = %v, want an error about the ConfigMap", err)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+	"github.com/liweinan/k8s-example/operator-example/internal/controller"
+)
+
+// TestShardedManager runs the controllers against a manager whose cache only
+// holds shard "a", like an operator started with --shard=a.
+func TestShardedManager(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+		BinaryAssetsDirectory: filepath.Join("..", "bin", "k8s",
+			fmt.Sprintf("1.29.0-%s-%s", runtime.GOOS, runtime.GOARCH)),
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("starting the test environment: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := testEnv.Stop(); err != nil {
+			t.Errorf("stopping the test environment: %v", err)
+		}
+	})
+
+	cacheOpts, err := cacheOptions("", "", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:  scheme,
+		Cache:   cacheOpts,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			t.Errorf("starting the manager: %v", err)
+		}
+	}()
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		t.Fatal("cache did not sync")
+	}
+
+	// k8sClient bypasses the cache, like kubectl
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inShard := func(shard string) map[string]string {
+		return map[string]string{shardLabel: shard}
+	}
+	// cached waits until the manager cache holds key
+	cached := func(t *testing.T, key types.NamespacedName, obj client.Object) {
+		t.Helper()
+		err := wait.PollUntilContextTimeout(ctx, 50*time.Millisecond, 10*time.Second, true, func(ctx context.Context) (bool, error) {
+			return mgr.GetClient().Get(ctx, key, obj) == nil, nil
+		})
+		if err != nil {
+			t.Fatalf("%s never showed up in the cache: %v", key, err)
+		}
+	}
+
+	t.Run("ApplicationSet", func(t *testing.T) {
+		appSet := &appsv1alpha1.ApplicationSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "sharded", Labels: inShard("a")},
+			Spec: appsv1alpha1.ApplicationSetSpec{
+				Template: appsv1alpha1.ApplicationTemplate{
+					Spec: appsv1alpha1.ApplicationSpec{Image: "nginx:latest", Replicas: ptr.To[int32](1), Port: 80},
+				},
+				Generators: []appsv1alpha1.ApplicationSetGenerator{{
+					List: &appsv1alpha1.ListGenerator{Elements: []appsv1alpha1.ApplicationSetTarget{
+						{Namespace: "default", Name: "sharded-web"},
+					}},
+				}},
+			},
+		}
+		if err := k8sClient.Create(ctx, appSet); err != nil {
+			t.Fatal(err)
+		}
+		cached(t, types.NamespacedName{Name: appSet.Name}, &appsv1alpha1.ApplicationSet{})
+
+		reconciler := &controller.ApplicationSetReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: appSet.Name}}
+		if _, err := reconciler.Reconcile(ctx, request); err != nil {
+			t.Fatalf("first reconcile: %v", err)
+		}
+
+		// The Application controller reads v1beta1 Applications
+		cached(t, types.NamespacedName{Namespace: "default", Name: "sharded-web"}, &appsv1beta1.Application{})
+		if _, err := reconciler.Reconcile(ctx, request); err != nil {
+			t.Fatalf("second reconcile: %v", err)
+		}
+
+		got := &appsv1alpha1.ApplicationSet{}
+		if err := k8sClient.Get(ctx, request.NamespacedName, got); err != nil {
+			t.Fatal(err)
+		}
+		if meta.IsStatusConditionTrue(got.Status.Conditions, appsv1alpha1.ConditionConflict) {
+			t.Errorf("ApplicationSet reports a conflict with its own Application: %v", got.Status.Conditions)
+		}
+		if len(got.Status.Applications) != 1 {
+			t.Errorf("status lists Applications %v, want sharded-web", got.Status.Applications)
+		}
+	})
+
+	t.Run("dependency in another shard", func(t *testing.T) {
+		backend := &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "default", Labels: inShard("b")},
+			Spec:       appsv1beta1.ApplicationSpec{Image: "nginx:latest"},
+		}
+		if err := k8sClient.Create(ctx, backend); err != nil {
+			t.Fatal(err)
+		}
+		meta.SetStatusCondition(&backend.Status.Conditions, metav1.Condition{
+			Type:   appsv1beta1.ConditionAvailable,
+			Status: metav1.ConditionTrue,
+			Reason: "MinimumReplicasAvailable",
+		})
+		if err := k8sClient.Status().Update(ctx, backend); err != nil {
+			t.Fatal(err)
+		}
+
+		frontend := &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default", Labels: inShard("a")},
+			Spec: appsv1beta1.ApplicationSpec{
+				Image:     "nginx:latest",
+				DependsOn: []appsv1beta1.ApplicationReference{{Name: "backend"}},
+			},
+		}
+		if err := k8sClient.Create(ctx, frontend); err != nil {
+			t.Fatal(err)
+		}
+		key := types.NamespacedName{Namespace: "default", Name: "frontend"}
+		cached(t, key, &appsv1beta1.Application{})
+
+		reconciler := &controller.ApplicationReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			APIReader: mgr.GetAPIReader(),
+		}
+		if _, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key}); err != nil {
+			t.Fatal(err)
+		}
+		if err := k8sClient.Get(ctx, key, &appsv1.Deployment{}); err != nil {
+			got := &appsv1beta1.Application{}
+			_ = k8sClient.Get(ctx, key, got)
+			t.Fatalf("the Deployment was not created (%v), conditions: %v", err, got.Status.Conditions)
+		}
+	})
+}
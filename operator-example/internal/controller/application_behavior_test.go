@@ -0,0 +1,510 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+// envtest runs an API server without controllers, so nothing updates the
+// status of Deployments or deletes the objects owned by a deleted
+// Application. The helpers below stand in for the deployment controller.
+
+// setDeploymentStatus reports replicas pods of which ready are ready and
+// available are available, like the deployment controller would.
+func setDeploymentStatus(ctx context.Context, key types.NamespacedName, replicas, ready, available int32) {
+	dep := &appsv1.Deployment{}
+	Expect(k8sClient.Get(ctx, key, dep)).To(Succeed())
+
+	condition := appsv1.DeploymentCondition{
+		Type:   appsv1.DeploymentAvailable,
+		Status: corev1.ConditionFalse,
+		Reason: "MinimumReplicasUnavailable",
+	}
+	if dep.Spec.Replicas != nil && available >= *dep.Spec.Replicas {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "MinimumReplicasAvailable"
+	}
+	dep.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: dep.Generation,
+		Replicas:           replicas,
+		UpdatedReplicas:    replicas,
+		ReadyReplicas:      ready,
+		AvailableReplicas:  available,
+		Conditions:         []appsv1.DeploymentCondition{condition},
+	}
+	Expect(k8sClient.Status().Update(ctx, dep)).To(Succeed())
+}
+
+// markDeploymentReady reports every desired replica of the Deployment as ready.
+func markDeploymentReady(ctx context.Context, key types.NamespacedName) {
+	dep := &appsv1.Deployment{}
+	Expect(k8sClient.Get(ctx, key, dep)).To(Succeed())
+	replicas := ptr.Deref(dep.Spec.Replicas, 1)
+	setDeploymentStatus(ctx, key, replicas, replicas, replicas)
+}
+
+var _ = Describe("Application Controller behavior", func() {
+	const resourceName = "behavior-app"
+
+	ctx := context.Background()
+
+	key := types.NamespacedName{
+		Name:      resourceName,
+		Namespace: "default",
+	}
+
+	newApplication := func() *appsv1beta1.Application {
+		return &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      resourceName,
+				Namespace: "default",
+			},
+			Spec: appsv1beta1.ApplicationSpec{
+				Image:    "nginx:1.25.3",
+				Replicas: ptr.To[int32](2),
+				Ports:    []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}},
+			},
+		}
+	}
+
+	reconcileApplication := func() {
+		controllerReconciler := &ApplicationReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: key,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// reconcileFully runs the reconciles needed to create the Deployment,
+	// the Service and the status, in place of the watch events
+	reconcileFully := func() {
+		reconcileApplication()
+		reconcileApplication()
+		reconcileApplication()
+	}
+
+	getApplication := func() *appsv1beta1.Application {
+		app := &appsv1beta1.Application{}
+		Expect(k8sClient.Get(ctx, key, app)).To(Succeed())
+		return app
+	}
+
+	getDeployment := func() *appsv1.Deployment {
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, key, dep)).To(Succeed())
+		return dep
+	}
+
+	getService := func() *corev1.Service {
+		svc := &corev1.Service{}
+		Expect(k8sClient.Get(ctx, key, svc)).To(Succeed())
+		return svc
+	}
+
+	AfterEach(func() {
+		By("Cleanup the Application and its resources")
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		}))).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		}))).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		}))).To(Succeed())
+	})
+
+	Context("When an Application is created", func() {
+		It("should create a Deployment and a Service controlled by the Application", func() {
+			Expect(k8sClient.Create(ctx, newApplication())).To(Succeed())
+			reconcileFully()
+			app := getApplication()
+
+			dep := getDeployment()
+			Expect(metav1.IsControlledBy(dep, app)).To(BeTrue())
+			Expect(dep.Spec.Replicas).To(Equal(ptr.To(int32(2))))
+			Expect(dep.Spec.Selector.MatchLabels).To(Equal(map[string]string{"app": resourceName}))
+			Expect(dep.Spec.Template.Labels).To(HaveKeyWithValue("app", resourceName))
+			Expect(dep.Spec.Template.Spec.Containers).To(HaveLen(1))
+			container := dep.Spec.Template.Spec.Containers[0]
+			Expect(container.Image).To(Equal("nginx:1.25.3"))
+			Expect(container.Ports).To(ConsistOf(corev1.ContainerPort{
+				Name: "http", ContainerPort: 80, Protocol: corev1.ProtocolTCP,
+			}))
+
+			svc := getService()
+			Expect(metav1.IsControlledBy(svc, app)).To(BeTrue())
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+			Expect(svc.Spec.Selector).To(Equal(map[string]string{"app": resourceName}))
+			Expect(svc.Spec.Ports).To(HaveLen(1))
+			Expect(svc.Spec.Ports[0].Port).To(Equal(int32(80)))
+			Expect(svc.Spec.Ports[0].TargetPort.IntValue()).To(Equal(80))
+
+			By("reporting the new Application as owned but not yet Available")
+			Expect(app.Status.Selector).To(Equal("app=" + resourceName))
+			conflict := meta.FindStatusCondition(app.Status.Conditions, appsv1beta1.ConditionConflict)
+			Expect(conflict).NotTo(BeNil())
+			Expect(conflict.Status).To(Equal(metav1.ConditionFalse))
+			Expect(meta.IsStatusConditionFalse(app.Status.Conditions, appsv1beta1.ConditionAvailable)).To(BeTrue())
+		})
+	})
+
+	Context("When the spec of an Application changes", func() {
+		DescribeTable("should roll the change out to the owned objects",
+			func(update func(*appsv1beta1.Application), check func(*appsv1.Deployment, *corev1.Service)) {
+				Expect(k8sClient.Create(ctx, newApplication())).To(Succeed())
+				reconcileFully()
+
+				app := getApplication()
+				update(app)
+				Expect(k8sClient.Update(ctx, app)).To(Succeed())
+				reconcileApplication()
+
+				check(getDeployment(), getService())
+			},
+			Entry("image",
+				func(app *appsv1beta1.Application) { app.Spec.Image = "nginx:1.27.0" },
+				func(dep *appsv1.Deployment, _ *corev1.Service) {
+					Expect(dep.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27.0"))
+					Expect(dep.Labels).To(HaveKeyWithValue("app.kubernetes.io/version", "1.27.0"))
+				}),
+			Entry("replicas",
+				func(app *appsv1beta1.Application) { app.Spec.Replicas = ptr.To[int32](4) },
+				func(dep *appsv1.Deployment, _ *corev1.Service) {
+					Expect(dep.Spec.Replicas).To(Equal(ptr.To(int32(4))))
+				}),
+			Entry("ports",
+				func(app *appsv1beta1.Application) {
+					app.Spec.Ports = []appsv1beta1.ApplicationPort{
+						{Name: "web", ContainerPort: 8080},
+						{Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+					}
+				},
+				func(dep *appsv1.Deployment, svc *corev1.Service) {
+					Expect(dep.Spec.Template.Spec.Containers[0].Ports).To(ConsistOf(
+						corev1.ContainerPort{Name: "web", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+						corev1.ContainerPort{Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+					))
+					Expect(svc.Spec.Ports).To(HaveLen(2))
+					Expect(svc.Spec.Ports[0].Name).To(Equal("web"))
+					Expect(svc.Spec.Ports[0].Port).To(Equal(int32(8080)))
+					Expect(svc.Spec.Ports[1].Name).To(Equal("dns"))
+					Expect(svc.Spec.Ports[1].Protocol).To(Equal(corev1.ProtocolUDP))
+				}),
+			Entry("resources",
+				func(app *appsv1beta1.Application) {
+					app.Spec.Resources = corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+					}
+				},
+				func(dep *appsv1.Deployment, _ *corev1.Service) {
+					resources := dep.Spec.Template.Spec.Containers[0].Resources
+					Expect(resources.Requests.Cpu().String()).To(Equal("250m"))
+					Expect(resources.Limits.Memory().String()).To(Equal("256Mi"))
+				}),
+			Entry("env",
+				func(app *appsv1beta1.Application) {
+					app.Spec.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+				},
+				func(dep *appsv1.Deployment, _ *corev1.Service) {
+					Expect(dep.Spec.Template.Spec.Containers[0].Env).To(ConsistOf(
+						corev1.EnvVar{Name: "LOG_LEVEL", Value: "debug"},
+					))
+				}),
+			Entry("podLabels",
+				func(app *appsv1beta1.Application) {
+					app.Spec.PodLabels = map[string]string{"team": "payments", "app": "hijacked"}
+				},
+				func(dep *appsv1.Deployment, _ *corev1.Service) {
+					Expect(dep.Spec.Template.Labels).To(HaveKeyWithValue("team", "payments"))
+					// The selector labels cannot be overridden
+					Expect(dep.Spec.Template.Labels).To(HaveKeyWithValue("app", resourceName))
+				}),
+			Entry("podAnnotations",
+				func(app *appsv1beta1.Application) {
+					app.Spec.PodAnnotations = map[string]string{"prometheus.io/scrape": "true"}
+				},
+				func(dep *appsv1.Deployment, _ *corev1.Service) {
+					Expect(dep.Spec.Template.Annotations).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+				}),
+			Entry("serviceAnnotations",
+				func(app *appsv1beta1.Application) {
+					app.Spec.ServiceAnnotations = map[string]string{"example.com/lb": "internal"}
+				},
+				func(_ *appsv1.Deployment, svc *corev1.Service) {
+					Expect(svc.Annotations).To(HaveKeyWithValue("example.com/lb", "internal"))
+				}),
+		)
+	})
+
+	Context("When a key is removed from the labels or annotations of an Application", func() {
+		DescribeTable("should remove it from the owned objects and keep keys added by others",
+			func(set func(*appsv1beta1.Application, map[string]string), get func(*appsv1.Deployment, *corev1.Service) map[string]string) {
+				app := newApplication()
+				set(app, map[string]string{"team": "payments", "tier": "backend"})
+				Expect(k8sClient.Create(ctx, app)).To(Succeed())
+				reconcileFully()
+				Expect(get(getDeployment(), getService())).To(HaveKeyWithValue("tier", "backend"))
+
+				By("adding a key behind the operator's back")
+				dep, svc := getDeployment(), getService()
+				get(dep, svc)["added-by"] = "someone-else"
+				Expect(k8sClient.Update(ctx, dep)).To(Succeed())
+				Expect(k8sClient.Update(ctx, svc)).To(Succeed())
+
+				app = getApplication()
+				set(app, map[string]string{"team": "payments"})
+				Expect(k8sClient.Update(ctx, app)).To(Succeed())
+				reconcileApplication()
+
+				current := get(getDeployment(), getService())
+				Expect(current).To(HaveKeyWithValue("team", "payments"))
+				Expect(current).NotTo(HaveKey("tier"))
+				Expect(current).To(HaveKeyWithValue("added-by", "someone-else"))
+			},
+			Entry("podLabels",
+				func(app *appsv1beta1.Application, m map[string]string) { app.Spec.PodLabels = m },
+				func(dep *appsv1.Deployment, _ *corev1.Service) map[string]string { return dep.Spec.Template.Labels }),
+			Entry("podAnnotations",
+				func(app *appsv1beta1.Application, m map[string]string) { app.Spec.PodAnnotations = m },
+				func(dep *appsv1.Deployment, _ *corev1.Service) map[string]string {
+					return dep.Spec.Template.Annotations
+				}),
+			Entry("serviceAnnotations",
+				func(app *appsv1beta1.Application, m map[string]string) { app.Spec.ServiceAnnotations = m },
+				func(_ *appsv1.Deployment, svc *corev1.Service) map[string]string { return svc.Annotations }),
+		)
+	})
+
+	Context("When the Deployment reports its status", func() {
+		DescribeTable("should propagate it to the Application",
+			func(replicas, ready, available int32, wantStatus metav1.ConditionStatus, wantReason string) {
+				Expect(k8sClient.Create(ctx, newApplication())).To(Succeed())
+				reconcileFully()
+
+				setDeploymentStatus(ctx, key, replicas, ready, available)
+				reconcileApplication()
+
+				app := getApplication()
+				Expect(app.Status.Replicas).To(Equal(replicas))
+				Expect(app.Status.ReadyReplicas).To(Equal(ready))
+				Expect(app.Status.AvailableReplicas).To(Equal(available))
+				Expect(app.Status.UpdatedReplicas).To(Equal(replicas))
+				condition := meta.FindStatusCondition(app.Status.Conditions, appsv1beta1.ConditionAvailable)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(wantStatus))
+				Expect(condition.Reason).To(Equal(wantReason))
+				Expect(condition.ObservedGeneration).To(Equal(app.Generation))
+			},
+			Entry("no pods yet", int32(0), int32(0), int32(0), metav1.ConditionFalse, "ReplicasUnavailable"),
+			Entry("some pods available", int32(2), int32(1), int32(1), metav1.ConditionFalse, "ReplicasUnavailable"),
+			Entry("all pods available", int32(2), int32(2), int32(2), metav1.ConditionTrue, "MinimumReplicasAvailable"),
+		)
+
+		It("should become unavailable again when scaled up", func() {
+			Expect(k8sClient.Create(ctx, newApplication())).To(Succeed())
+			reconcileFully()
+			markDeploymentReady(ctx, key)
+			reconcileApplication()
+			Expect(meta.IsStatusConditionTrue(getApplication().Status.Conditions, appsv1beta1.ConditionAvailable)).To(BeTrue())
+
+			app := getApplication()
+			app.Spec.Replicas = ptr.To[int32](3)
+			Expect(k8sClient.Update(ctx, app)).To(Succeed())
+			reconcileApplication()
+			Expect(meta.IsStatusConditionFalse(getApplication().Status.Conditions, appsv1beta1.ConditionAvailable)).To(BeTrue())
+
+			markDeploymentReady(ctx, key)
+			reconcileApplication()
+			app = getApplication()
+			Expect(app.Status.AvailableReplicas).To(Equal(int32(3)))
+			Expect(meta.IsStatusConditionTrue(app.Status.Conditions, appsv1beta1.ConditionAvailable)).To(BeTrue())
+		})
+	})
+
+	Context("When an Application is deleted", func() {
+		It("should leave the owned objects to the garbage collector", func() {
+			Expect(k8sClient.Create(ctx, newApplication())).To(Succeed())
+			reconcileFully()
+			app := getApplication()
+
+			By("owning the objects so that the garbage collector deletes them")
+			for _, obj := range []client.Object{getDeployment(), getService()} {
+				owner := metav1.GetControllerOf(obj)
+				Expect(owner).NotTo(BeNil())
+				Expect(owner.UID).To(Equal(app.UID))
+				Expect(owner.Kind).To(Equal("Application"))
+				Expect(owner.BlockOwnerDeletion).To(Equal(ptr.To(true)))
+			}
+
+			By("ignoring the deleted Application")
+			Expect(k8sClient.Delete(ctx, app)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, getDeployment())).To(Succeed())
+			Expect(k8sClient.Delete(ctx, getService())).To(Succeed())
+			reconcileApplication()
+			err := k8sClient.Get(ctx, key, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			err = k8sClient.Get(ctx, key, &corev1.Service{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("When an Application is invalid", func() {
+		DescribeTable("should be rejected by the API server",
+			func(update func(*appsv1beta1.Application)) {
+				app := newApplication()
+				update(app)
+				err := k8sClient.Create(ctx, app)
+				Expect(errors.IsInvalid(err)).To(BeTrue(), "unexpected error: %v", err)
+			},
+			Entry("negative replicas", func(app *appsv1beta1.Application) {
+				app.Spec.Replicas = ptr.To[int32](-1)
+			}),
+			Entry("port name not a DNS label", func(app *appsv1beta1.Application) {
+				app.Spec.Ports[0].Name = "HTTP"
+			}),
+			Entry("port out of range", func(app *appsv1beta1.Application) {
+				app.Spec.Ports[0].ContainerPort = 70000
+			}),
+			Entry("unknown protocol", func(app *appsv1beta1.Application) {
+				app.Spec.Ports[0].Protocol = "HTTP"
+			}),
+			Entry("unknown drift policy", func(app *appsv1beta1.Application) {
+				app.Spec.DriftPolicy = "Sometimes"
+			}),
+			Entry("image policy with both semver and tagPattern", func(app *appsv1beta1.Application) {
+				app.Spec.ImagePolicy = &appsv1beta1.ImagePolicy{Semver: "~1.25", TagPattern: "^1\\.25\\."}
+			}),
+			Entry("image policy without semver or tagPattern", func(app *appsv1beta1.Application) {
+				app.Spec.ImagePolicy = &appsv1beta1.ImagePolicy{}
+			}),
+		)
+	})
+
+	Context("When the objects of an Application belong to someone else", func() {
+		otherOwner := []metav1.OwnerReference{{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       "other-owner",
+			UID:        "2bb3ec58-3f3e-4a14-9f5e-5b0d3a6b4d3b",
+			Controller: ptr.To(true),
+		}}
+
+		newDeployment := func(owners []metav1.OwnerReference) client.Object {
+			return &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default", OwnerReferences: owners},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": resourceName}},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": resourceName}},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "other", Image: "busybox"}},
+						},
+					},
+				},
+			}
+		}
+
+		newService := func(owners []metav1.OwnerReference) client.Object {
+			return &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default", OwnerReferences: owners},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{{Name: "other", Port: 9090}},
+				},
+			}
+		}
+
+		DescribeTable("should report a Conflict and leave the object alone",
+			func(existing client.Object, annotations map[string]string, wantMessage string) {
+				Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+				app := newApplication()
+				app.Annotations = annotations
+				Expect(k8sClient.Create(ctx, app)).To(Succeed())
+				reconcileFully()
+
+				condition := meta.FindStatusCondition(getApplication().Status.Conditions, appsv1beta1.ConditionConflict)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(condition.Reason).To(Equal("ResourceExists"))
+				Expect(condition.Message).To(ContainSubstring(wantMessage))
+
+				current := existing.DeepCopyObject().(client.Object)
+				Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+				Expect(current.GetOwnerReferences()).To(Equal(existing.GetOwnerReferences()))
+			},
+			Entry("unowned Deployment", newDeployment(nil), nil,
+				"set annotation "+appsv1beta1.AdoptAnnotation+"=true to adopt it"),
+			Entry("Deployment controlled by another object", newDeployment(otherOwner),
+				map[string]string{appsv1beta1.AdoptAnnotation: "true"}, "is controlled by ConfigMap other-owner"),
+			Entry("unowned Service", newService(nil), nil,
+				"Service "+resourceName+" already exists"),
+			Entry("Service controlled by another object", newService(otherOwner),
+				map[string]string{appsv1beta1.AdoptAnnotation: "true"}, "is controlled by ConfigMap other-owner"),
+		)
+
+		It("should clear the Conflict once the object is adopted", func() {
+			Expect(k8sClient.Create(ctx, newService(nil))).To(Succeed())
+			Expect(k8sClient.Create(ctx, newApplication())).To(Succeed())
+			reconcileFully()
+			Expect(meta.IsStatusConditionTrue(getApplication().Status.Conditions, appsv1beta1.ConditionConflict)).To(BeTrue())
+
+			app := getApplication()
+			app.Annotations = map[string]string{appsv1beta1.AdoptAnnotation: "true"}
+			Expect(k8sClient.Update(ctx, app)).To(Succeed())
+			reconcileFully()
+
+			app = getApplication()
+			Expect(metav1.IsControlledBy(getService(), app)).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(app.Status.Conditions, appsv1beta1.ConditionConflict)).To(BeTrue())
+			Expect(getService().Spec.Ports[0].Port).To(Equal(int32(80)))
+		})
+
+		It("should not fail when the Application changes between reconciles", func() {
+			Expect(k8sClient.Create(ctx, newApplication())).To(Succeed())
+			reconcileApplication()
+
+			By("updating the Application while it is being reconciled")
+			app := getApplication()
+			app.Spec.Image = "nginx:1.27.0"
+			Expect(k8sClient.Update(ctx, app)).To(Succeed())
+			reconcileFully()
+
+			Expect(getDeployment().Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27.0"))
+			Expect(getApplication().Status.Selector).To(Equal("app=" + resourceName))
+		})
+	})
+})
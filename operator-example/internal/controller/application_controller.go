@@ -18,27 +18,70 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"golang.org/x/time/rate"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
 )
 
 // ApplicationReconciler reconciles a Application object
 type ApplicationReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// APIReader reads the direct dependencies in dependsOn, which may live outside
+	// the namespaces, selector or shard of the manager cache. The Client is
+	// used when nil.
+	APIReader client.Reader
+
+	// MaxConcurrentReconciles is the number of Applications reconciled in
+	// parallel, the controller-runtime default of 1 when zero
+	MaxConcurrentReconciles int
+
+	// RequeueInterval returns how long to wait before checking an Application
+	// again when nothing else triggers it, one minute when nil. It is called
+	// on every reconcile so that the interval can change at runtime.
+	RequeueInterval func() time.Duration
+
+	// RequeueJitter returns the fraction of the requeue interval up to which
+	// a random delay is added, so that Applications created together are not
+	// all checked again at the same time. No delay is added when nil. Like
+	// RequeueInterval it is called on every reconcile.
+	RequeueJitter func() float64
+
+	// RateLimiter limits how fast failed Applications are retried and how
+	// fast requests are processed overall, see NewRateLimiter. The
+	// controller-runtime default is used when nil.
+	RateLimiter workqueue.RateLimiter
+}
+
+// NewRateLimiter returns a rate limiter retrying an Application after
+// baseDelay, doubling the delay on every failure up to maxDelay, while
+// processing at most qps requests per second overall with bursts of burst.
+func NewRateLimiter(baseDelay, maxDelay time.Duration, qps, burst int) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
 }
 
 //+kubebuilder:rbac:groups=apps.example.com,resources=applications,verbs=get;list;watch;create;update;patch;delete
@@ -60,7 +103,7 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	log := log.FromContext(ctx)
 
 	// Fetch the Application instance
-	application := &appsv1alpha1.Application{}
+	application := &appsv1beta1.Application{}
 	err := r.Get(ctx, req.NamespacedName, application)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -74,10 +117,25 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Check the Applications in spec.dependsOn
+	dependencies, err := r.dependencyCondition(ctx, application)
+	if err != nil {
+		log.Error(err, "Failed to check Application dependencies")
+		return ctrl.Result{}, err
+	}
+
 	// Check if the deployment already exists, if not create a new one
 	foundDeployment := &appsv1.Deployment{}
 	err = r.Get(ctx, types.NamespacedName{Name: application.Name, Namespace: application.Namespace}, foundDeployment)
 	if err != nil && errors.IsNotFound(err) {
+		// Hold the first rollout back until every dependency is Available.
+		// Once the deployment exists it is kept in sync whatever happens to
+		// the dependencies, the condition only reports them.
+		if dependencies != nil && dependencies.Status == metav1.ConditionTrue {
+			log.Info("Waiting for dependencies", "reason", dependencies.Reason, "message", dependencies.Message)
+			return r.setCondition(ctx, application, *dependencies)
+		}
+
 		// Define a new deployment
 		dep := r.deploymentForApplication(application)
 		log.Info("Creating a new Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
@@ -86,13 +144,32 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			log.Error(err, "Failed to create new Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
 			return ctrl.Result{}, err
 		}
-		// Deployment created successfully - return and requeue
-		return ctrl.Result{Requeue: true}, nil
+		// Deployment created successfully - the watch on owned Deployments
+		// triggers the next reconcile
+		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get Deployment")
 		return ctrl.Result{}, err
 	}
 
+	// Make sure the deployment is ours before reading its status
+	conflict, err := r.claimObject(ctx, application, foundDeployment, "Deployment")
+	if err != nil {
+		log.Error(err, "Failed to adopt Deployment", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
+		return ctrl.Result{}, err
+	}
+	if conflict != "" {
+		return r.reportConflict(ctx, application, conflict)
+	}
+
+	// Roll out changes of the Application and handle changes made to the
+	// deployment outside of the operator according to the drift policy
+	drift, err := r.syncDeployment(ctx, application, foundDeployment)
+	if err != nil {
+		log.Error(err, "Failed to update Deployment", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
+		return ctrl.Result{}, err
+	}
+
 	// Check if the service already exists, if not create a new one
 	foundService := &corev1.Service{}
 	err = r.Get(ctx, types.NamespacedName{Name: application.Name, Namespace: application.Namespace}, foundService)
@@ -105,72 +182,147 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			log.Error(err, "Failed to create new Service", "Service.Namespace", svc.Namespace, "Service.Name", svc.Name)
 			return ctrl.Result{}, err
 		}
-		// Service created successfully - return and requeue
-		return ctrl.Result{Requeue: true}, nil
+		// Service created successfully - the watch on owned Services
+		// triggers the next reconcile
+		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get Service")
 		return ctrl.Result{}, err
 	}
 
+	conflict, err = r.claimObject(ctx, application, foundService, "Service")
+	if err != nil {
+		log.Error(err, "Failed to adopt Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
+		return ctrl.Result{}, err
+	}
+	if conflict != "" {
+		return r.reportConflict(ctx, application, conflict)
+	}
+
+	serviceDrift, err := r.syncService(ctx, application, foundService)
+	if err != nil {
+		log.Error(err, "Failed to update Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
+		return ctrl.Result{}, err
+	}
+	drift = append(drift, serviceDrift...)
+
 	// Update the Application status with the deployment status
-	if err := r.updateApplicationStatus(ctx, application, foundDeployment); err != nil {
+	if err := r.updateApplicationStatus(ctx, application, foundDeployment, drift, dependencies); err != nil {
 		log.Error(err, "Failed to update Application status")
-		return ctrl.Result{Requeue: true}, nil
+		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{RequeueAfter: time.Minute}, nil
+	return ctrl.Result{RequeueAfter: r.requeueInterval()}, nil
 }
 
-// deploymentForApplication returns a application Deployment object
-func (r *ApplicationReconciler) deploymentForApplication(app *appsv1alpha1.Application) *appsv1.Deployment {
-	labels := map[string]string{
-		"app": app.Name,
+// requeueInterval returns how long to wait before checking an Application again
+func (r *ApplicationReconciler) requeueInterval() time.Duration {
+	interval := time.Minute
+	if r.RequeueInterval != nil {
+		interval = r.RequeueInterval()
+	}
+	if r.RequeueJitter != nil {
+		if jitter := r.RequeueJitter(); jitter > 0 {
+			interval = wait.Jitter(interval, jitter)
+		}
+	}
+	return interval
+}
+
+// claimObject makes sure obj may be managed by app. Objects already controlled
+// by app are accepted as they are. Objects without a controller are adopted
+// only when app carries the AdoptAnnotation. A non-empty message is returned
+// when obj must be left alone.
+func (r *ApplicationReconciler) claimObject(ctx context.Context, app *appsv1beta1.Application, obj client.Object, kind string) (string, error) {
+	if metav1.IsControlledBy(obj, app) {
+		return "", nil
+	}
+	if owner := metav1.GetControllerOf(obj); owner != nil {
+		return fmt.Sprintf("%s %s is controlled by %s %s", kind, obj.GetName(), owner.Kind, owner.Name), nil
+	}
+	if app.Annotations[appsv1beta1.AdoptAnnotation] != "true" {
+		return fmt.Sprintf("%s %s already exists and is not managed by this Application; set annotation %s=true to adopt it",
+			kind, obj.GetName(), appsv1beta1.AdoptAnnotation), nil
 	}
 
-	// Create resource requirements
-	resources := corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse(app.Spec.Resources.CPURequest),
-			corev1.ResourceMemory: resource.MustParse(app.Spec.Resources.MemoryRequest),
-		},
-		Limits: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse(app.Spec.Resources.CPULimit),
-			corev1.ResourceMemory: resource.MustParse(app.Spec.Resources.MemoryLimit),
-		},
+	log.FromContext(ctx).Info("Adopting existing "+kind, kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+	if err := ctrl.SetControllerReference(app, obj, r.Scheme); err != nil {
+		return "", err
+	}
+	return "", r.Update(ctx, obj)
+}
+
+// reportConflict records a Conflict condition on the Application and waits
+// for the conflicting object to be released or for the user to opt in to adoption.
+func (r *ApplicationReconciler) reportConflict(ctx context.Context, app *appsv1beta1.Application, message string) (ctrl.Result, error) {
+	log.FromContext(ctx).Info("Refusing to manage existing object", "reason", message)
+	return r.setCondition(ctx, app, metav1.Condition{
+		Type:    appsv1beta1.ConditionConflict,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ResourceExists",
+		Message: message,
+	})
+}
+
+// setCondition records condition on the Application when reconciliation
+// cannot proceed, and checks back later in case nothing else triggers it.
+func (r *ApplicationReconciler) setCondition(ctx context.Context, app *appsv1beta1.Application, condition metav1.Condition) (ctrl.Result, error) {
+	appCopy := app.DeepCopy()
+	condition.ObservedGeneration = app.Generation
+	meta.SetStatusCondition(&appCopy.Status.Conditions, condition)
+	if err := r.Status().Patch(ctx, appCopy, client.MergeFrom(app)); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update Application status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.requeueInterval()}, nil
+}
+
+// replicas returns the desired number of pods of app. The API server defaults
+// spec.replicas, so it is only unset for objects that were never stored.
+func replicas(app *appsv1beta1.Application) int32 {
+	return ptr.Deref(app.Spec.Replicas, 1)
+}
+
+// deploymentForApplication returns a application Deployment object
+func (r *ApplicationReconciler) deploymentForApplication(app *appsv1beta1.Application) *appsv1.Deployment {
+	// Create container ports
+	var ports []corev1.ContainerPort
+	for _, port := range app.Spec.Ports {
+		ports = append(ports, corev1.ContainerPort{
+			ContainerPort: port.ContainerPort,
+			Name:          port.Name,
+			Protocol:      portProtocol(port),
+		})
 	}
 
 	// Create environment variables
 	var envVars []corev1.EnvVar
 	for _, env := range app.Spec.Env {
-		envVars = append(envVars, corev1.EnvVar{
-			Name:  env.Name,
-			Value: env.Value,
-		})
+		envVars = append(envVars, *env.DeepCopy())
 	}
 
 	dep := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      app.Name,
 			Namespace: app.Namespace,
+			Labels:    standardLabels(app),
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &app.Spec.Replicas,
+			Replicas: ptr.To(replicas(app)),
 			Selector: &metav1.LabelSelector{
-				MatchLabels: labels,
+				MatchLabels: selectorLabels(app),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      podLabels(app),
+					Annotations: mergeMaps(app.Spec.PodAnnotations),
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{{
-						Image: app.Spec.Image,
-						Name:  app.Name,
-						Ports: []corev1.ContainerPort{{
-							ContainerPort: app.Spec.Port,
-							Name:          "http",
-						}},
-						Resources: resources,
+						Image:     containerImage(app),
+						Name:      app.Name,
+						Ports:     ports,
+						Resources: *app.Spec.Resources.DeepCopy(),
 						Env:       envVars,
 					}},
 				},
@@ -184,25 +336,28 @@ func (r *ApplicationReconciler) deploymentForApplication(app *appsv1alpha1.Appli
 }
 
 // serviceForApplication returns a application Service object
-func (r *ApplicationReconciler) serviceForApplication(app *appsv1alpha1.Application) *corev1.Service {
-	labels := map[string]string{
-		"app": app.Name,
+func (r *ApplicationReconciler) serviceForApplication(app *appsv1beta1.Application) *corev1.Service {
+	var ports []corev1.ServicePort
+	for _, port := range app.Spec.Ports {
+		ports = append(ports, corev1.ServicePort{
+			Port:       port.ContainerPort,
+			TargetPort: intstr.FromInt(int(port.ContainerPort)),
+			Protocol:   portProtocol(port),
+			Name:       port.Name,
+		})
 	}
 
 	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      app.Name,
-			Namespace: app.Namespace,
+			Name:        app.Name,
+			Namespace:   app.Namespace,
+			Labels:      standardLabels(app),
+			Annotations: mergeMaps(app.Spec.ServiceAnnotations),
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: labels,
-			Ports: []corev1.ServicePort{{
-				Port:       app.Spec.Port,
-				TargetPort: intstr.FromInt(int(app.Spec.Port)),
-				Protocol:   corev1.ProtocolTCP,
-				Name:       "http",
-			}},
-			Type: corev1.ServiceTypeClusterIP,
+			Selector: selectorLabels(app),
+			Ports:    ports,
+			Type:     corev1.ServiceTypeClusterIP,
 		},
 	}
 
@@ -211,15 +366,69 @@ func (r *ApplicationReconciler) serviceForApplication(app *appsv1alpha1.Applicat
 	return svc
 }
 
-// updateApplicationStatus updates the status of the Application resource
-func (r *ApplicationReconciler) updateApplicationStatus(ctx context.Context, app *appsv1alpha1.Application, deployment *appsv1.Deployment) error {
+// containerImage returns the image to run for app: the image resolved from
+// the image policy once there is one, spec.image otherwise.
+func containerImage(app *appsv1beta1.Application) string {
+	if app.Spec.ImagePolicy != nil && app.Status.ResolvedImage != "" {
+		return app.Status.ResolvedImage
+	}
+	return app.Spec.Image
+}
+
+// portProtocol returns the protocol of port, defaulting to TCP.
+func portProtocol(port appsv1beta1.ApplicationPort) corev1.Protocol {
+	if port.Protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// updateApplicationStatus updates the status of the Application resource.
+// dependencies is the WaitingForDependencies condition, nil when the
+// Application has no dependencies.
+func (r *ApplicationReconciler) updateApplicationStatus(ctx context.Context, app *appsv1beta1.Application, deployment *appsv1.Deployment, drift []appsv1beta1.DriftedField, dependencies *metav1.Condition) error {
 	// Create a copy of the application to modify
 	appCopy := app.DeepCopy()
 
 	// Update the status
+	appCopy.Status.Replicas = deployment.Status.Replicas
+	appCopy.Status.Selector = labels.SelectorFromSet(selectorLabels(app)).String()
 	appCopy.Status.AvailableReplicas = deployment.Status.AvailableReplicas
 	appCopy.Status.ReadyReplicas = deployment.Status.ReadyReplicas
 	appCopy.Status.UpdatedReplicas = deployment.Status.UpdatedReplicas
+	appCopy.Status.Drift = drift
+	meta.SetStatusCondition(&appCopy.Status.Conditions, metav1.Condition{
+		Type:               appsv1beta1.ConditionConflict,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Owned",
+		Message:            "Deployment and Service are controlled by this Application",
+		ObservedGeneration: app.Generation,
+	})
+	if dependencies != nil {
+		condition := *dependencies
+		condition.ObservedGeneration = app.Generation
+		meta.SetStatusCondition(&appCopy.Status.Conditions, condition)
+	} else {
+		meta.RemoveStatusCondition(&appCopy.Status.Conditions, appsv1beta1.ConditionWaitingForDependencies)
+	}
+	desired := replicas(app)
+	if deployment.Status.AvailableReplicas >= desired {
+		meta.SetStatusCondition(&appCopy.Status.Conditions, metav1.Condition{
+			Type:               appsv1beta1.ConditionAvailable,
+			Status:             metav1.ConditionTrue,
+			Reason:             "MinimumReplicasAvailable",
+			Message:            fmt.Sprintf("%d of %d replicas available", deployment.Status.AvailableReplicas, desired),
+			ObservedGeneration: app.Generation,
+		})
+	} else {
+		meta.SetStatusCondition(&appCopy.Status.Conditions, metav1.Condition{
+			Type:               appsv1beta1.ConditionAvailable,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ReplicasUnavailable",
+			Message:            fmt.Sprintf("%d of %d replicas available", deployment.Status.AvailableReplicas, desired),
+			ObservedGeneration: app.Generation,
+		})
+	}
 
 	// Use Patch instead of Update to avoid conflicts
 	return r.Status().Patch(ctx, appCopy, client.MergeFrom(app))
@@ -227,9 +436,18 @@ func (r *ApplicationReconciler) updateApplicationStatus(ctx context.Context, app
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &appsv1beta1.Application{}, dependsOnIndex, indexDependsOn); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&appsv1alpha1.Application{}).
+		For(&appsv1beta1.Application{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Watches(&appsv1beta1.Application{}, handler.EnqueueRequestsFromMapFunc(r.dependentsOf)).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+			RateLimiter:             r.RateLimiter,
+		}).
 		Complete(r)
 }
@@ -18,16 +18,25 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
 )
 
 var _ = Describe("Application Controller", func() {
@@ -40,18 +49,21 @@ var _ = Describe("Application Controller", func() {
 			Name:      resourceName,
 			Namespace: "default", // TODO(user):Modify as needed
 		}
-		application := &appsv1alpha1.Application{}
+		application := &appsv1beta1.Application{}
 
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind Application")
 			err := k8sClient.Get(ctx, typeNamespacedName, application)
 			if err != nil && errors.IsNotFound(err) {
-				resource := &appsv1alpha1.Application{
+				resource := &appsv1beta1.Application{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					// TODO(user): Specify other spec details if needed.
+					Spec: appsv1beta1.ApplicationSpec{
+						Image:    "nginx:latest",
+						Replicas: ptr.To[int32](1),
+					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
@@ -59,7 +71,7 @@ var _ = Describe("Application Controller", func() {
 
 		AfterEach(func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &appsv1alpha1.Application{}
+			resource := &appsv1beta1.Application{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -81,4 +93,264 @@ var _ = Describe("Application Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When an object with the Application's name already exists", func() {
+		const resourceName = "existing-app"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		newApplication := func(annotations map[string]string) *appsv1beta1.Application {
+			return &appsv1beta1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        resourceName,
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: appsv1beta1.ApplicationSpec{
+					Image:    "nginx:latest",
+					Replicas: ptr.To[int32](1),
+					Ports:    []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("100m"),
+							corev1.ResourceMemory: resource.MustParse("128Mi"),
+						},
+					},
+				},
+			}
+		}
+
+		reconcileApplication := func() {
+			controllerReconciler := &ApplicationReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			By("creating a hand-written Deployment without an owner")
+			labels := map[string]string{"app": resourceName}
+			dep := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: resourceName, Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, dep)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the Application and the Deployment")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1beta1.Application{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+		})
+
+		It("should report a Conflict instead of taking the Deployment over", func() {
+			Expect(k8sClient.Create(ctx, newApplication(nil))).To(Succeed())
+			reconcileApplication()
+
+			dep := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+			Expect(metav1.GetControllerOf(dep)).To(BeNil())
+
+			app := &appsv1beta1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, app)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(app.Status.Conditions, appsv1beta1.ConditionConflict)).To(BeTrue())
+		})
+
+		It("should adopt the Deployment when the Application opts in", func() {
+			Expect(k8sClient.Create(ctx, newApplication(map[string]string{
+				appsv1beta1.AdoptAnnotation: "true",
+			}))).To(Succeed())
+			reconcileApplication()
+
+			app := &appsv1beta1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, app)).To(Succeed())
+			dep := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+			Expect(metav1.IsControlledBy(dep, app)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(app.Status.Conditions, appsv1beta1.ConditionConflict)).To(BeFalse())
+		})
+	})
+
+	Context("When the Application is scaled", func() {
+		const resourceName = "scaled-app"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		reconcileApplication := func() {
+			controllerReconciler := &ApplicationReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &appsv1beta1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: appsv1beta1.ApplicationSpec{
+					Image:    "nginx:latest",
+					Replicas: ptr.To[int32](1),
+					Ports:    []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the Application and its resources")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1beta1.Application{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+		})
+
+		It("should scale the Deployment and publish the pod selector", func() {
+			By("creating the Deployment and the Service")
+			reconcileApplication()
+			reconcileApplication()
+			reconcileApplication()
+
+			app := &appsv1beta1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, app)).To(Succeed())
+			Expect(app.Status.Selector).To(Equal("app=" + resourceName))
+
+			By("changing spec.replicas like the scale subresource does")
+			app.Spec.Replicas = ptr.To[int32](5)
+			Expect(k8sClient.Update(ctx, app)).To(Succeed())
+			reconcileApplication()
+
+			dep := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+			Expect(dep.Spec.Replicas).NotTo(BeNil())
+			Expect(*dep.Spec.Replicas).To(Equal(int32(5)))
+		})
+
+		It("should scale the Deployment to zero through the scale subresource", func() {
+			reconcileApplication()
+			reconcileApplication()
+
+			app := &appsv1beta1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, app)).To(Succeed())
+			scale := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 0}}
+			Expect(k8sClient.SubResource("scale").Update(ctx, app, client.WithSubResourceBody(scale))).To(Succeed())
+			reconcileApplication()
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, app)).To(Succeed())
+			Expect(app.Spec.Replicas).To(Equal(ptr.To[int32](0)))
+			dep := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+			Expect(dep.Spec.Replicas).To(Equal(ptr.To[int32](0)))
+			Expect(meta.IsStatusConditionTrue(app.Status.Conditions, appsv1beta1.ConditionAvailable)).To(BeTrue())
+		})
+	})
+
+	Context("When the requeue policy is configured", func() {
+		const resourceName = "requeue-app"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		reconcileApplication := func() ctrl.Result {
+			controllerReconciler := &ApplicationReconciler{
+				Client:          k8sClient,
+				Scheme:          k8sClient.Scheme(),
+				RequeueInterval: func() time.Duration { return 10 * time.Minute },
+				RequeueJitter:   func() float64 { return 0.5 },
+			}
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return result
+		}
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &appsv1beta1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: appsv1beta1.ApplicationSpec{
+					Image:    "nginx:latest",
+					Replicas: ptr.To[int32](1),
+					Ports:    []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the Application and its resources")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1beta1.Application{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+		})
+
+		It("should wait for watch events after creating objects and jitter the resync", func() {
+			By("creating the Deployment without requeueing")
+			Expect(reconcileApplication()).To(Equal(ctrl.Result{}))
+
+			By("creating the Service without requeueing")
+			Expect(reconcileApplication()).To(Equal(ctrl.Result{}))
+
+			By("resyncing after the jittered interval")
+			for i := 0; i < 5; i++ {
+				result := reconcileApplication()
+				Expect(result.Requeue).To(BeFalse())
+				Expect(result.RequeueAfter).To(BeNumerically(">=", 10*time.Minute))
+				Expect(result.RequeueAfter).To(BeNumerically("<=", 15*time.Minute))
+			}
+		})
+	})
 })
@@ -0,0 +1,288 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+)
+
+// ApplicationSetReconciler reconciles an ApplicationSet object
+type ApplicationSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=apps.example.com,resources=applicationsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.example.com,resources=applicationsets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.example.com,resources=applicationsets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// Reconcile stamps out one Application per target produced by the generators
+// of an ApplicationSet and removes the Applications of targets that went away.
+func (r *ApplicationSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// Fetch the ApplicationSet instance
+	appSet := &appsv1alpha1.ApplicationSet{}
+	err := r.Get(ctx, req.NamespacedName, appSet)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Generated Applications are garbage collected through their owner reference
+			log.Info("ApplicationSet resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get ApplicationSet")
+		return ctrl.Result{}, err
+	}
+
+	targets, err := r.targetsForApplicationSet(ctx, appSet)
+	if err != nil {
+		log.Error(err, "Failed to generate ApplicationSet targets")
+		return ctrl.Result{}, err
+	}
+
+	// Create or update the Application of every target
+	var generated []appsv1alpha1.ApplicationReference
+	var conflicts []string
+	wanted := map[types.NamespacedName]bool{}
+	for _, target := range targets {
+		key := types.NamespacedName{Namespace: target.Namespace, Name: target.Name}
+		wanted[key] = true
+
+		// Never touch an Application somebody else created with the same name
+		app := &appsv1alpha1.Application{}
+		err := r.Get(ctx, key, app)
+		if err == nil && !metav1.IsControlledBy(app, appSet) {
+			conflicts = append(conflicts, fmt.Sprintf("Application %s is not controlled by this ApplicationSet", key))
+			continue
+		} else if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to get Application", "Application.Namespace", key.Namespace, "Application.Name", key.Name)
+			return ctrl.Result{}, err
+		}
+
+		app = &appsv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: target.Name, Namespace: target.Namespace},
+		}
+		op, err := controllerutil.CreateOrUpdate(ctx, r.Client, app, func() error {
+			return r.mutateApplication(appSet, target, app)
+		})
+		if err != nil {
+			log.Error(err, "Failed to create or update Application", "Application.Namespace", key.Namespace, "Application.Name", key.Name)
+			return ctrl.Result{}, err
+		}
+		if op != controllerutil.OperationResultNone {
+			log.Info("Generated Application", "Application.Namespace", key.Namespace, "Application.Name", key.Name, "operation", op)
+		}
+		generated = append(generated, appsv1alpha1.ApplicationReference{Name: key.Name, Namespace: key.Namespace})
+	}
+
+	// Delete Applications whose target is gone
+	existing := &appsv1alpha1.ApplicationList{}
+	if err := r.List(ctx, existing, client.MatchingLabels{appsv1alpha1.ApplicationSetLabel: appSet.Name}); err != nil {
+		log.Error(err, "Failed to list generated Applications")
+		return ctrl.Result{}, err
+	}
+	for i := range existing.Items {
+		app := &existing.Items[i]
+		key := types.NamespacedName{Namespace: app.Namespace, Name: app.Name}
+		if wanted[key] || !metav1.IsControlledBy(app, appSet) {
+			continue
+		}
+		log.Info("Deleting Application of removed target", "Application.Namespace", key.Namespace, "Application.Name", key.Name)
+		if err := r.Delete(ctx, app); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to delete Application", "Application.Namespace", key.Namespace, "Application.Name", key.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.updateApplicationSetStatus(ctx, appSet, generated, conflicts); err != nil {
+		log.Error(err, "Failed to update ApplicationSet status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// targetsForApplicationSet runs all generators of appSet and returns the
+// resulting targets with their names defaulted, sorted by namespace and name.
+// When several generators produce the same target the first one wins.
+func (r *ApplicationSetReconciler) targetsForApplicationSet(ctx context.Context, appSet *appsv1alpha1.ApplicationSet) ([]appsv1alpha1.ApplicationSetTarget, error) {
+	var targets []appsv1alpha1.ApplicationSetTarget
+	seen := map[types.NamespacedName]bool{}
+	add := func(target appsv1alpha1.ApplicationSetTarget) {
+		if target.Name == "" {
+			target.Name = appSet.Name
+		}
+		key := types.NamespacedName{Namespace: target.Namespace, Name: target.Name}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		targets = append(targets, target)
+	}
+
+	for _, generator := range appSet.Spec.Generators {
+		if generator.List != nil {
+			for _, element := range generator.List.Elements {
+				add(element)
+			}
+		}
+		if generator.Namespaces != nil {
+			selector, err := metav1.LabelSelectorAsSelector(&generator.Namespaces.Selector)
+			if err != nil {
+				return nil, err
+			}
+			namespaces := &corev1.NamespaceList{}
+			if err := r.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+				return nil, err
+			}
+			for _, ns := range namespaces.Items {
+				if ns.DeletionTimestamp != nil {
+					continue
+				}
+				add(appsv1alpha1.ApplicationSetTarget{Namespace: ns.Name, Overrides: generator.Namespaces.Overrides})
+			}
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Namespace != targets[j].Namespace {
+			return targets[i].Namespace < targets[j].Namespace
+		}
+		return targets[i].Name < targets[j].Name
+	})
+	return targets, nil
+}
+
+// mutateApplication sets the metadata and spec of app from the template of
+// appSet and the overrides of target. The shard of appSet is copied to app,
+// so that a sharded operator sees the Applications it generated.
+func (r *ApplicationSetReconciler) mutateApplication(appSet *appsv1alpha1.ApplicationSet, target appsv1alpha1.ApplicationSetTarget, app *appsv1alpha1.Application) error {
+	template := appSet.Spec.Template
+	owned := map[string]string{appsv1alpha1.ApplicationSetLabel: appSet.Name}
+	if shard, ok := appSet.Labels[appsv1alpha1.ShardLabel]; ok {
+		owned[appsv1alpha1.ShardLabel] = shard
+	}
+	app.Labels = mergeMaps(app.Labels, template.Labels, owned)
+	app.Annotations = mergeMaps(app.Annotations, template.Annotations)
+	app.Spec = *template.Spec.DeepCopy()
+	applyOverrides(&app.Spec, target.Overrides)
+
+	// Set ApplicationSet instance as the owner and controller
+	return ctrl.SetControllerReference(appSet, app, r.Scheme)
+}
+
+// applyOverrides applies the per-target overrides to spec.
+func applyOverrides(spec *appsv1alpha1.ApplicationSpec, overrides *appsv1alpha1.ApplicationOverrides) {
+	if overrides == nil {
+		return
+	}
+	if overrides.Image != "" {
+		spec.Image = overrides.Image
+	}
+	if overrides.Replicas != nil {
+		spec.Replicas = ptr.To(*overrides.Replicas)
+	}
+	if overrides.Resources != nil {
+		spec.Resources = *overrides.Resources
+	}
+	for _, env := range overrides.Env {
+		replaced := false
+		for i := range spec.Env {
+			if spec.Env[i].Name == env.Name {
+				spec.Env[i] = env
+				replaced = true
+			}
+		}
+		if !replaced {
+			spec.Env = append(spec.Env, env)
+		}
+	}
+	spec.PodLabels = mergeMaps(spec.PodLabels, overrides.PodLabels)
+}
+
+// updateApplicationSetStatus records the generated Applications and any conflicts.
+func (r *ApplicationSetReconciler) updateApplicationSetStatus(ctx context.Context, appSet *appsv1alpha1.ApplicationSet, generated []appsv1alpha1.ApplicationReference, conflicts []string) error {
+	appSetCopy := appSet.DeepCopy()
+	appSetCopy.Status.Applications = generated
+
+	condition := metav1.Condition{
+		Type:               appsv1alpha1.ConditionConflict,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Owned",
+		Message:            fmt.Sprintf("%d Applications generated", len(generated)),
+		ObservedGeneration: appSet.Generation,
+	}
+	if len(conflicts) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ResourceExists"
+		condition.Message = strings.Join(conflicts, "; ")
+	}
+	meta.SetStatusCondition(&appSetCopy.Status.Conditions, condition)
+
+	// Use Patch instead of Update to avoid conflicts
+	return r.Status().Patch(ctx, appSetCopy, client.MergeFrom(appSet))
+}
+
+// applicationSetsForNamespace maps a Namespace to reconcile requests for every
+// ApplicationSet with a namespace generator, so that labelling a namespace
+// creates or removes its Application.
+func (r *ApplicationSetReconciler) applicationSetsForNamespace(ctx context.Context, _ client.Object) []reconcile.Request {
+	appSets := &appsv1alpha1.ApplicationSetList{}
+	if err := r.List(ctx, appSets); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ApplicationSets")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, appSet := range appSets.Items {
+		for _, generator := range appSet.Spec.Generators {
+			if generator.Namespaces != nil {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: appSet.Name}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ApplicationSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1alpha1.ApplicationSet{}).
+		Owns(&appsv1alpha1.Application{}).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.applicationSetsForNamespace)).
+		Complete(r)
+}
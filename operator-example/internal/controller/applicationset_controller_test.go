@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+)
+
+var _ = Describe("ApplicationSet Controller", func() {
+	Context("When reconciling an ApplicationSet", func() {
+		const resourceName = "tenant-app"
+
+		ctx := context.Background()
+
+		reconcileApplicationSet := func() {
+			controllerReconciler := &ApplicationSetReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: resourceName},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			By("creating a labelled and an unlabelled tenant namespace")
+			for name, labels := range map[string]map[string]string{
+				"appset-tenant-a": {"example.com/tenant": "true"},
+				"appset-tenant-b": nil,
+				"appset-staging":  nil,
+			} {
+				ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+				Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, ns))).To(Succeed())
+			}
+
+			By("creating the custom resource for the Kind ApplicationSet")
+			appSet := &appsv1alpha1.ApplicationSet{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+				Spec: appsv1alpha1.ApplicationSetSpec{
+					Template: appsv1alpha1.ApplicationTemplate{
+						Labels: map[string]string{"team": "platform"},
+						Spec: appsv1alpha1.ApplicationSpec{
+							Image:    "nginx:latest",
+							Replicas: ptr.To[int32](1),
+							Port:     80,
+							Env:      []appsv1alpha1.EnvVar{{Name: "ENVIRONMENT", Value: "production"}},
+						},
+					},
+					Generators: []appsv1alpha1.ApplicationSetGenerator{
+						{Namespaces: &appsv1alpha1.NamespaceGenerator{
+							Selector: metav1.LabelSelector{MatchLabels: map[string]string{"example.com/tenant": "true"}},
+						}},
+						{List: &appsv1alpha1.ListGenerator{Elements: []appsv1alpha1.ApplicationSetTarget{{
+							Namespace: "appset-staging",
+							Overrides: &appsv1alpha1.ApplicationOverrides{
+								Image:    "nginx:1.25",
+								Replicas: ptr.To[int32](2),
+								Env:      []appsv1alpha1.EnvVar{{Name: "ENVIRONMENT", Value: "staging"}},
+							},
+						}}}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, appSet)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the ApplicationSet and its Applications")
+			Expect(k8sClient.Delete(ctx, &appsv1alpha1.ApplicationSet{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+			})).To(Succeed())
+			for _, ns := range []string{"appset-tenant-a", "appset-tenant-b", "appset-staging"} {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1alpha1.Application{
+					ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: ns},
+				}))).To(Succeed())
+			}
+		})
+
+		It("should stamp out one Application per target with overrides applied", func() {
+			reconcileApplicationSet()
+
+			appSet := &appsv1alpha1.ApplicationSet{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName}, appSet)).To(Succeed())
+
+			tenant := &appsv1alpha1.Application{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "appset-tenant-a", Name: resourceName}, tenant)).To(Succeed())
+			Expect(metav1.IsControlledBy(tenant, appSet)).To(BeTrue())
+			Expect(tenant.Labels).To(HaveKeyWithValue("team", "platform"))
+			Expect(tenant.Labels).To(HaveKeyWithValue(appsv1alpha1.ApplicationSetLabel, resourceName))
+			Expect(tenant.Spec.Image).To(Equal("nginx:latest"))
+
+			staging := &appsv1alpha1.Application{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "appset-staging", Name: resourceName}, staging)).To(Succeed())
+			Expect(staging.Spec.Image).To(Equal("nginx:1.25"))
+			Expect(staging.Spec.Replicas).To(Equal(ptr.To[int32](2)))
+			Expect(staging.Spec.Env).To(Equal([]appsv1alpha1.EnvVar{{Name: "ENVIRONMENT", Value: "staging"}}))
+
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "appset-tenant-b", Name: resourceName}, &appsv1alpha1.Application{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Expect(appSet.Status.Applications).To(ConsistOf(
+				appsv1alpha1.ApplicationReference{Namespace: "appset-staging", Name: resourceName},
+				appsv1alpha1.ApplicationReference{Namespace: "appset-tenant-a", Name: resourceName},
+			))
+		})
+
+		It("should delete the Application of a target that went away", func() {
+			reconcileApplicationSet()
+
+			By("removing the static list generator")
+			appSet := &appsv1alpha1.ApplicationSet{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName}, appSet)).To(Succeed())
+			appSet.Spec.Generators = appSet.Spec.Generators[:1]
+			Expect(k8sClient.Update(ctx, appSet)).To(Succeed())
+
+			reconcileApplicationSet()
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "appset-staging", Name: resourceName}, &appsv1alpha1.Application{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "appset-tenant-a", Name: resourceName}, &appsv1alpha1.Application{})).To(Succeed())
+		})
+
+		It("should not take over an Application it does not control", func() {
+			By("creating an unrelated Application in a target namespace")
+			Expect(k8sClient.Create(ctx, &appsv1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "appset-staging"},
+				Spec:       appsv1alpha1.ApplicationSpec{Image: "busybox:latest", Replicas: ptr.To[int32](1), Port: 80},
+			})).To(Succeed())
+
+			reconcileApplicationSet()
+
+			existing := &appsv1alpha1.Application{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "appset-staging", Name: resourceName}, existing)).To(Succeed())
+			Expect(existing.Spec.Image).To(Equal("busybox:latest"))
+			Expect(metav1.GetControllerOf(existing)).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,215 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+// dependsOnIndex indexes Applications by the namespaced names of the
+// Applications they depend on
+const dependsOnIndex = ".spec.dependsOn"
+
+// dependencies returns the namespaced names of the Applications app depends on.
+func dependencies(app *appsv1beta1.Application) []types.NamespacedName {
+	var deps []types.NamespacedName
+	for _, ref := range app.Spec.DependsOn {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = app.Namespace
+		}
+		deps = append(deps, types.NamespacedName{Namespace: namespace, Name: ref.Name})
+	}
+	return deps
+}
+
+// indexDependsOn is the indexer function for dependsOnIndex.
+func indexDependsOn(obj client.Object) []string {
+	app, ok := obj.(*appsv1beta1.Application)
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for _, dep := range dependencies(app) {
+		keys = append(keys, dep.String())
+	}
+	return keys
+}
+
+// dependentsOf maps an Application to reconcile requests for every
+// Application that depends on it, so that dependents continue as soon as
+// their dependency becomes Available.
+func (r *ApplicationReconciler) dependentsOf(ctx context.Context, obj client.Object) []reconcile.Request {
+	dependents := &appsv1beta1.ApplicationList{}
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}.String()
+	if err := r.List(ctx, dependents, client.MatchingFields{dependsOnIndex: key}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list dependent Applications", "Application", key)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(dependents.Items))
+	for _, dependent := range dependents.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: dependent.Namespace, Name: dependent.Name},
+		})
+	}
+	return requests
+}
+
+// dependencyReader returns the reader used to look up the Applications in
+// dependsOn. They are read from the API server because they do not have to be
+// in the cache of this operator instance. Only the direct dependencies are
+// read this way; the cycle check walks the cache.
+func (r *ApplicationReconciler) dependencyReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// dependencyCondition returns the WaitingForDependencies condition of app,
+// or nil when app has no dependencies.
+func (r *ApplicationReconciler) dependencyCondition(ctx context.Context, app *appsv1beta1.Application) (*metav1.Condition, error) {
+	if len(app.Spec.DependsOn) == 0 {
+		return nil, nil
+	}
+	reason, message, err := r.checkDependencies(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return &metav1.Condition{
+			Type:    appsv1beta1.ConditionWaitingForDependencies,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		}, nil
+	}
+	return &metav1.Condition{
+		Type:    appsv1beta1.ConditionWaitingForDependencies,
+		Status:  metav1.ConditionFalse,
+		Reason:  "DependenciesAvailable",
+		Message: "All Applications in dependsOn are Available",
+	}, nil
+}
+
+// checkDependencies walks the dependency graph of app. It returns a reason
+// and message for the WaitingForDependencies condition when app has to wait,
+// or empty strings when every dependency is Available.
+func (r *ApplicationReconciler) checkDependencies(ctx context.Context, app *appsv1beta1.Application) (string, string, error) {
+	self := types.NamespacedName{Namespace: app.Namespace, Name: app.Name}
+
+	var missing, forbidden, unavailable []string
+	direct := dependencies(app)
+	for _, dep := range direct {
+		if dep == self {
+			return "DependencyCycle", fmt.Sprintf("Application %s depends on itself", self), nil
+		}
+		found := &appsv1beta1.Application{}
+		if err := r.dependencyReader().Get(ctx, dep, found); err != nil {
+			if errors.IsNotFound(err) {
+				missing = append(missing, dep.String())
+				continue
+			}
+			// The RBAC of a namespaced operator does not cover other namespaces
+			if errors.IsForbidden(err) {
+				forbidden = append(forbidden, dep.String())
+				continue
+			}
+			return "", "", err
+		}
+		if !meta.IsStatusConditionTrue(found.Status.Conditions, appsv1beta1.ConditionAvailable) {
+			unavailable = append(unavailable, dep.String())
+		}
+	}
+
+	// Follow the graph from the direct dependencies and refuse to start if it
+	// leads back here, otherwise the Applications would wait for each other forever
+	if path := r.findPath(ctx, direct, self); path != nil {
+		return "DependencyCycle", "Dependency cycle: " + strings.Join(append([]string{self.String()}, path...), " -> "), nil
+	}
+
+	if len(missing) > 0 {
+		return "DependencyNotFound", "Waiting for missing Applications: " + strings.Join(missing, ", "), nil
+	}
+	if len(forbidden) > 0 {
+		return "DependencyForbidden", "Not allowed to read Applications: " + strings.Join(forbidden, ", ") +
+			"; the operator may be restricted to other namespaces", nil
+	}
+	if len(unavailable) > 0 {
+		return "DependencyNotAvailable", "Waiting for Applications to become Available: " + strings.Join(unavailable, ", "), nil
+	}
+	return "", "", nil
+}
+
+// findPath searches the dependency graph breadth first from start and
+// returns the chain of Applications leading to target, or nil if target
+// cannot be reached. The graph is read from the cache, so Applications
+// outside of it (another shard or an unwatched namespace) are not followed.
+func (r *ApplicationReconciler) findPath(ctx context.Context, start []types.NamespacedName, target types.NamespacedName) []string {
+	parent := map[types.NamespacedName]types.NamespacedName{}
+	visited := map[types.NamespacedName]bool{}
+	queue := append([]types.NamespacedName{}, start...)
+	for _, name := range start {
+		visited[name] = true
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		found := &appsv1beta1.Application{}
+		if err := r.Get(ctx, current, found); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			// The cache of a namespaced operator refuses other namespaces
+			log.FromContext(ctx).V(1).Info("Not following dependency outside the cache", "Application", current, "reason", err.Error())
+			continue
+		}
+		for _, next := range dependencies(found) {
+			if next == target {
+				path := []string{target.String()}
+				for node := current; ; node = parent[node] {
+					path = append([]string{node.String()}, path...)
+					if _, ok := parent[node]; !ok {
+						break
+					}
+				}
+				return path
+			}
+			if !visited[next] {
+				visited[next] = true
+				parent[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
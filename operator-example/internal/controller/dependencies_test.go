@@ -0,0 +1,258 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+var _ = Describe("Application dependencies", func() {
+	ctx := context.Background()
+
+	newApplication := func(name string, dependsOn ...string) *appsv1beta1.Application {
+		app := &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: appsv1beta1.ApplicationSpec{
+				Image:    "nginx:latest",
+				Replicas: ptr.To[int32](1),
+				Ports:    []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+				},
+			},
+		}
+		for _, dep := range dependsOn {
+			app.Spec.DependsOn = append(app.Spec.DependsOn, appsv1beta1.ApplicationReference{Name: dep})
+		}
+		return app
+	}
+
+	reconcileApplication := func(name string) {
+		controllerReconciler := &ApplicationReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: name},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	waitingCondition := func(name string) *metav1.Condition {
+		app := &appsv1beta1.Application{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, app)).To(Succeed())
+		return meta.FindStatusCondition(app.Status.Conditions, appsv1beta1.ConditionWaitingForDependencies)
+	}
+
+	// setAvailable sets the Available condition of the Application name, in
+	// place of its own reconciles
+	setAvailable := func(name string, status metav1.ConditionStatus) {
+		app := &appsv1beta1.Application{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, app)).To(Succeed())
+		meta.SetStatusCondition(&app.Status.Conditions, metav1.Condition{
+			Type:   appsv1beta1.ConditionAvailable,
+			Status: status,
+			Reason: "Test",
+		})
+		Expect(k8sClient.Status().Update(ctx, app)).To(Succeed())
+	}
+
+	cleanup := func(names ...string) {
+		for _, name := range names {
+			objectMeta := metav1.ObjectMeta{Name: name, Namespace: "default"}
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1beta1.Application{ObjectMeta: objectMeta}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1.Deployment{ObjectMeta: objectMeta}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Service{ObjectMeta: objectMeta}))).To(Succeed())
+		}
+	}
+
+	It("should index Applications by their dependencies", func() {
+		app := newApplication("worker", "api")
+		app.Spec.DependsOn = append(app.Spec.DependsOn, appsv1beta1.ApplicationReference{Name: "db-proxy", Namespace: "infra"})
+		Expect(indexDependsOn(app)).To(ConsistOf("default/api", "infra/db-proxy"))
+	})
+
+	It("should wait until the dependency is Available", func() {
+		DeferCleanup(cleanup, "api", "worker")
+		Expect(k8sClient.Create(ctx, newApplication("api"))).To(Succeed())
+		Expect(k8sClient.Create(ctx, newApplication("worker", "api"))).To(Succeed())
+
+		By("reconciling the worker before the api is Available")
+		reconcileApplication("worker")
+		condition := waitingCondition("worker")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("DependencyNotAvailable"))
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "worker"}, &appsv1.Deployment{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		By("marking the api Available")
+		setAvailable("api", metav1.ConditionTrue)
+
+		reconcileApplication("worker")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "worker"}, &appsv1.Deployment{})).To(Succeed())
+	})
+
+	It("should keep syncing a running Application when a dependency becomes unavailable", func() {
+		DeferCleanup(cleanup, "api", "worker")
+		workerKey := types.NamespacedName{Namespace: "default", Name: "worker"}
+		Expect(k8sClient.Create(ctx, newApplication("api"))).To(Succeed())
+		setAvailable("api", metav1.ConditionTrue)
+		Expect(k8sClient.Create(ctx, newApplication("worker", "api"))).To(Succeed())
+		reconcileApplication("worker")
+		reconcileApplication("worker")
+		reconcileApplication("worker")
+		markDeploymentReady(ctx, workerKey)
+		reconcileApplication("worker")
+		Expect(waitingCondition("worker").Status).To(Equal(metav1.ConditionFalse))
+
+		By("making the api unavailable and scaling the worker")
+		setAvailable("api", metav1.ConditionFalse)
+		worker := &appsv1beta1.Application{}
+		Expect(k8sClient.Get(ctx, workerKey, worker)).To(Succeed())
+		worker.Spec.Replicas = ptr.To[int32](2)
+		Expect(k8sClient.Update(ctx, worker)).To(Succeed())
+		reconcileApplication("worker")
+
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, workerKey, dep)).To(Succeed())
+		Expect(*dep.Spec.Replicas).To(Equal(int32(2)))
+		condition := waitingCondition("worker")
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("DependencyNotAvailable"))
+
+		By("still reporting the status of the Deployment")
+		markDeploymentReady(ctx, workerKey)
+		reconcileApplication("worker")
+		Expect(k8sClient.Get(ctx, workerKey, worker)).To(Succeed())
+		Expect(worker.Status.AvailableReplicas).To(Equal(int32(2)))
+		Expect(meta.IsStatusConditionTrue(worker.Status.Conditions, appsv1beta1.ConditionAvailable)).To(BeTrue())
+	})
+
+	It("should report missing dependencies", func() {
+		DeferCleanup(cleanup, "orphan")
+		Expect(k8sClient.Create(ctx, newApplication("orphan", "does-not-exist"))).To(Succeed())
+
+		reconcileApplication("orphan")
+		condition := waitingCondition("orphan")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("DependencyNotFound"))
+	})
+
+	It("should wait for dependencies it is not allowed to read", func() {
+		DeferCleanup(cleanup, "restricted")
+		app := newApplication("restricted")
+		app.Spec.DependsOn = []appsv1beta1.ApplicationReference{{Name: "db-proxy", Namespace: "infra"}}
+		Expect(k8sClient.Create(ctx, app)).To(Succeed())
+
+		// Like a namespaced operator whose Role only covers "default"
+		controllerReconciler := &ApplicationReconciler{
+			Client:    k8sClient,
+			Scheme:    k8sClient.Scheme(),
+			APIReader: namespacedReader{Reader: k8sClient, namespace: "default"},
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: "restricted"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		condition := waitingCondition("restricted")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("DependencyForbidden"))
+		Expect(condition.Message).To(ContainSubstring("infra/db-proxy"))
+		err = k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "restricted"}, &appsv1.Deployment{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should detect dependency cycles", func() {
+		DeferCleanup(cleanup, "cycle-a", "cycle-b", "cycle-c")
+		Expect(k8sClient.Create(ctx, newApplication("cycle-a", "cycle-b"))).To(Succeed())
+		Expect(k8sClient.Create(ctx, newApplication("cycle-b", "cycle-c"))).To(Succeed())
+		Expect(k8sClient.Create(ctx, newApplication("cycle-c", "cycle-a"))).To(Succeed())
+
+		reconcileApplication("cycle-a")
+		condition := waitingCondition("cycle-a")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("DependencyCycle"))
+		Expect(condition.Message).To(ContainSubstring("default/cycle-a -> default/cycle-b -> default/cycle-c -> default/cycle-a"))
+	})
+
+	It("should only read the direct dependencies from the API server", func() {
+		DeferCleanup(cleanup, "chain-a", "chain-b", "chain-c")
+		Expect(k8sClient.Create(ctx, newApplication("chain-a", "chain-b"))).To(Succeed())
+		Expect(k8sClient.Create(ctx, newApplication("chain-b", "chain-c"))).To(Succeed())
+		Expect(k8sClient.Create(ctx, newApplication("chain-c"))).To(Succeed())
+
+		reader := &recordingReader{Reader: k8sClient}
+		controllerReconciler := &ApplicationReconciler{
+			Client:    k8sClient,
+			Scheme:    k8sClient.Scheme(),
+			APIReader: reader,
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: "chain-a"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reader.keys).To(ConsistOf(types.NamespacedName{Namespace: "default", Name: "chain-b"}))
+		Expect(waitingCondition("chain-a").Reason).To(Equal("DependencyNotAvailable"))
+	})
+})
+
+// namespacedReader refuses reads outside namespace, like the API server does
+// for an operator deployed with a Role.
+type namespacedReader struct {
+	client.Reader
+	namespace string
+}
+
+func (r namespacedReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if key.Namespace != r.namespace {
+		return errors.NewForbidden(appsv1beta1.GroupVersion.WithResource("applications").GroupResource(), key.Name,
+			fmt.Errorf("cannot get resource in namespace %q", key.Namespace))
+	}
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
+
+// recordingReader records the keys of the objects read through it.
+type recordingReader struct {
+	client.Reader
+	keys []types.NamespacedName
+}
+
+func (r *recordingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	r.keys = append(r.keys, key)
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
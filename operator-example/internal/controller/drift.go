@@ -0,0 +1,317 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+// desiredHashAnnotation records on an owned object the hash of the desired
+// state last applied by the operator. It tells changes of the Application
+// spec, which are always rolled out, apart from changes made to the object
+// behind the operator's back, which are handled by the drift policy.
+const desiredHashAnnotation = "apps.example.com/desired-hash"
+
+// managedKeysAnnotation records on an owned object the label and annotation
+// keys last applied by the operator. Keys that are no longer desired, for
+// example after an entry was removed from spec.podLabels, are deleted from the
+// object, while keys added by others are kept.
+const managedKeysAnnotation = "apps.example.com/managed-keys"
+
+// managedKeys is the content of managedKeysAnnotation
+type managedKeys struct {
+	Labels              []string `json:"labels,omitempty"`
+	Annotations         []string `json:"annotations,omitempty"`
+	TemplateLabels      []string `json:"templateLabels,omitempty"`
+	TemplateAnnotations []string `json:"templateAnnotations,omitempty"`
+}
+
+// syncDeployment brings an existing deployment to the desired state of app.
+// It returns the drift that was left in place because of the drift policy.
+func (r *ApplicationReconciler) syncDeployment(ctx context.Context, app *appsv1beta1.Application, dep *appsv1.Deployment) ([]appsv1beta1.DriftedField, error) {
+	desired := r.deploymentForApplication(app)
+	hash, err := hashOf(desired.Labels, desired.Spec)
+	if err != nil {
+		return nil, err
+	}
+	drift := diffDeployment(desired, dep)
+	return r.syncObject(ctx, app, dep, hash, drift, func() { revertDeployment(desired, dep) })
+}
+
+// syncService brings an existing service to the desired state of app. It
+// returns the drift that was left in place because of the drift policy.
+func (r *ApplicationReconciler) syncService(ctx context.Context, app *appsv1beta1.Application, svc *corev1.Service) ([]appsv1beta1.DriftedField, error) {
+	desired := r.serviceForApplication(app)
+	hash, err := hashOf(desired.Labels, desired.Annotations, desired.Spec)
+	if err != nil {
+		return nil, err
+	}
+	drift := diffService(desired, svc)
+	return r.syncObject(ctx, app, svc, hash, drift, func() { revertService(desired, svc) })
+}
+
+// syncObject applies the desired state to obj by calling revert when the
+// desired state changed since it was last applied, or when obj drifted and
+// the drift policy of app is Revert.
+func (r *ApplicationReconciler) syncObject(ctx context.Context, app *appsv1beta1.Application, obj client.Object, hash string, drift []appsv1beta1.DriftedField, revert func()) ([]appsv1beta1.DriftedField, error) {
+	applied := obj.GetAnnotations()[desiredHashAnnotation]
+	switch {
+	case applied == hash && len(drift) == 0:
+		return nil, nil
+	case applied == hash && app.Spec.DriftPolicy == appsv1beta1.DriftPolicyReport:
+		return drift, nil
+	case applied == hash && app.Spec.DriftPolicy == appsv1beta1.DriftPolicyIgnore:
+		return nil, nil
+	case applied == hash:
+		log.FromContext(ctx).Info("Reverting drift", "Kind", drift[0].Kind, "Name", obj.GetName(), "drift", drift)
+	}
+
+	revert()
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[desiredHashAnnotation] = hash
+	obj.SetAnnotations(annotations)
+	return nil, r.Update(ctx, obj)
+}
+
+// diffDeployment returns the fields managed by the operator in which actual
+// differs from desired.
+func diffDeployment(desired, actual *appsv1.Deployment) []appsv1beta1.DriftedField {
+	d := differ{kind: "Deployment", name: actual.Name}
+	d.labels("metadata.labels", desired.Labels, actual.Labels)
+	d.compare("spec.replicas", desired.Spec.Replicas, actual.Spec.Replicas)
+	d.labels("spec.template.metadata.labels", desired.Spec.Template.Labels, actual.Spec.Template.Labels)
+	d.labels("spec.template.metadata.annotations", desired.Spec.Template.Annotations, actual.Spec.Template.Annotations)
+
+	want := desired.Spec.Template.Spec.Containers[0]
+	index := containerIndex(actual, want.Name)
+	if index < 0 {
+		d.add("spec.template.spec.containers", want.Name, "")
+		return d.drift
+	}
+	have := actual.Spec.Template.Spec.Containers[index]
+	path := fmt.Sprintf("spec.template.spec.containers[%d]", index)
+	d.compare(path+".image", want.Image, have.Image)
+	d.compare(path+".ports", want.Ports, have.Ports)
+	d.compare(path+".env", defaultEnv(want.Env), have.Env)
+	d.compare(path+".resources", want.Resources, have.Resources)
+	return d.drift
+}
+
+// diffService returns the fields managed by the operator in which actual
+// differs from desired.
+func diffService(desired, actual *corev1.Service) []appsv1beta1.DriftedField {
+	d := differ{kind: "Service", name: actual.Name}
+	d.labels("metadata.labels", desired.Labels, actual.Labels)
+	d.labels("metadata.annotations", desired.Annotations, actual.Annotations)
+	d.compare("spec.type", desired.Spec.Type, actual.Spec.Type)
+	d.compare("spec.selector", desired.Spec.Selector, actual.Spec.Selector)
+	d.compare("spec.ports", desired.Spec.Ports, actual.Spec.Ports)
+	return d.drift
+}
+
+// revertDeployment copies the fields managed by the operator from desired to
+// actual. Labels and annotations added by others are kept, those previously
+// applied by the operator but no longer desired are removed, and the
+// selector, which is immutable, is left as it is.
+func revertDeployment(desired, actual *appsv1.Deployment) {
+	previous := readManagedKeys(actual)
+	actual.Labels = applyMap(actual.Labels, desired.Labels, previous.Labels)
+	actual.Spec.Replicas = desired.Spec.Replicas
+	actual.Spec.Template.Labels = applyMap(actual.Spec.Template.Labels, desired.Spec.Template.Labels, previous.TemplateLabels)
+	actual.Spec.Template.Annotations = applyMap(actual.Spec.Template.Annotations, desired.Spec.Template.Annotations, previous.TemplateAnnotations)
+	writeManagedKeys(actual, managedKeys{
+		Labels:              sortedKeys(desired.Labels),
+		TemplateLabels:      sortedKeys(desired.Spec.Template.Labels),
+		TemplateAnnotations: sortedKeys(desired.Spec.Template.Annotations),
+	})
+
+	want := desired.Spec.Template.Spec.Containers[0]
+	index := containerIndex(actual, want.Name)
+	if index < 0 {
+		actual.Spec.Template.Spec.Containers = append(actual.Spec.Template.Spec.Containers, want)
+		return
+	}
+	have := &actual.Spec.Template.Spec.Containers[index]
+	have.Image = want.Image
+	have.Ports = want.Ports
+	have.Env = want.Env
+	have.Resources = want.Resources
+}
+
+// revertService copies the fields managed by the operator from desired to
+// actual. Labels and annotations added by others are kept, those previously
+// applied by the operator but no longer desired are removed.
+func revertService(desired, actual *corev1.Service) {
+	previous := readManagedKeys(actual)
+	actual.Labels = applyMap(actual.Labels, desired.Labels, previous.Labels)
+	actual.Annotations = applyMap(actual.Annotations, desired.Annotations, previous.Annotations)
+	writeManagedKeys(actual, managedKeys{
+		Labels:      sortedKeys(desired.Labels),
+		Annotations: sortedKeys(desired.Annotations),
+	})
+	actual.Spec.Type = desired.Spec.Type
+	actual.Spec.Selector = desired.Spec.Selector
+	actual.Spec.Ports = desired.Spec.Ports
+}
+
+// applyMap returns actual without the keys of previous that are missing
+// from desired, and with the entries of desired added.
+func applyMap(actual, desired map[string]string, previous []string) map[string]string {
+	out := mergeMaps(actual, desired)
+	for _, key := range previous {
+		if _, ok := desired[key]; !ok {
+			delete(out, key)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// readManagedKeys returns the keys recorded in managedKeysAnnotation of obj.
+// Objects without the annotation, or with one that cannot be parsed, have
+// no recorded keys, so nothing is removed from them.
+func readManagedKeys(obj client.Object) managedKeys {
+	var keys managedKeys
+	if value, ok := obj.GetAnnotations()[managedKeysAnnotation]; ok {
+		_ = json.Unmarshal([]byte(value), &keys)
+	}
+	return keys
+}
+
+// writeManagedKeys records keys in managedKeysAnnotation of obj.
+func writeManagedKeys(obj client.Object, keys managedKeys) {
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[managedKeysAnnotation] = string(data)
+	obj.SetAnnotations(annotations)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// differ collects the drift of one owned object
+type differ struct {
+	kind  string
+	name  string
+	drift []appsv1beta1.DriftedField
+}
+
+// compare records path when actual is not semantically equal to desired.
+func (d *differ) compare(path string, desired, actual interface{}) {
+	if !equality.Semantic.DeepEqual(desired, actual) {
+		d.add(path, fieldValue(desired), fieldValue(actual))
+	}
+}
+
+// labels records every entry of desired that is missing or different in
+// actual. Extra entries in actual are not drift.
+func (d *differ) labels(path string, desired, actual map[string]string) {
+	for _, key := range sortedKeys(desired) {
+		if value, ok := actual[key]; !ok || value != desired[key] {
+			d.add(fmt.Sprintf("%s[%s]", path, key), desired[key], value)
+		}
+	}
+}
+
+func (d *differ) add(path, desired, actual string) {
+	d.drift = append(d.drift, appsv1beta1.DriftedField{
+		Kind:    d.kind,
+		Name:    d.name,
+		Path:    path,
+		Desired: desired,
+		Actual:  actual,
+	})
+}
+
+// fieldValue formats a field value for status.drift.
+func fieldValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// hashOf returns a short hash of the JSON encoding of values.
+func hashOf(values ...interface{}) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// containerIndex returns the index of the application container in dep: the
+// container called name, or else the first container of an adopted
+// deployment. It returns -1 when dep has no containers.
+func containerIndex(dep *appsv1.Deployment, name string) int {
+	for i, container := range dep.Spec.Template.Spec.Containers {
+		if container.Name == name {
+			return i
+		}
+	}
+	if len(dep.Spec.Template.Spec.Containers) > 0 {
+		return 0
+	}
+	return -1
+}
+
+// defaultEnv returns env with the defaults the API server applies to
+// environment variables, so that it compares equal to what is stored.
+func defaultEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	var out []corev1.EnvVar
+	for _, e := range env {
+		e = *e.DeepCopy()
+		if e.ValueFrom != nil && e.ValueFrom.FieldRef != nil && e.ValueFrom.FieldRef.APIVersion == "" {
+			e.ValueFrom.FieldRef.APIVersion = "v1"
+		}
+		out = append(out, e)
+	}
+	return out
+}
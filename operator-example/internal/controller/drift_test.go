@@ -0,0 +1,159 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+var _ = Describe("Drift of owned objects", func() {
+	const resourceName = "drifting-app"
+
+	ctx := context.Background()
+
+	typeNamespacedName := types.NamespacedName{
+		Name:      resourceName,
+		Namespace: "default",
+	}
+
+	reconcileApplication := func() {
+		controllerReconciler := &ApplicationReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: typeNamespacedName,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// setUp creates the Application with policy, lets the operator create
+	// its objects and then edits the Deployment image behind its back
+	setUp := func(policy appsv1beta1.DriftPolicy) {
+		Expect(k8sClient.Create(ctx, &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      resourceName,
+				Namespace: "default",
+			},
+			Spec: appsv1beta1.ApplicationSpec{
+				Image:       "nginx:1.25.3",
+				Replicas:    ptr.To[int32](2),
+				Ports:       []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}},
+				DriftPolicy: policy,
+			},
+		})).To(Succeed())
+		reconcileApplication()
+		reconcileApplication()
+		reconcileApplication()
+
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+		dep.Spec.Template.Spec.Containers[0].Image = "nginx:hotfix"
+		Expect(k8sClient.Update(ctx, dep)).To(Succeed())
+	}
+
+	deploymentImage := func() string {
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+		return dep.Spec.Template.Spec.Containers[0].Image
+	}
+
+	getApplication := func() *appsv1beta1.Application {
+		app := &appsv1beta1.Application{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, app)).To(Succeed())
+		return app
+	}
+
+	AfterEach(func() {
+		By("Cleanup the Application and its resources")
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		}))).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		}))).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		}))).To(Succeed())
+	})
+
+	It("should revert manual changes with the Revert policy", func() {
+		setUp(appsv1beta1.DriftPolicyRevert)
+		reconcileApplication()
+
+		Expect(deploymentImage()).To(Equal("nginx:1.25.3"))
+		Expect(getApplication().Status.Drift).To(BeEmpty())
+	})
+
+	It("should only report manual changes with the Report policy", func() {
+		setUp(appsv1beta1.DriftPolicyReport)
+		reconcileApplication()
+
+		Expect(deploymentImage()).To(Equal("nginx:hotfix"))
+		Expect(getApplication().Status.Drift).To(ConsistOf(appsv1beta1.DriftedField{
+			Kind:    "Deployment",
+			Name:    resourceName,
+			Path:    "spec.template.spec.containers[0].image",
+			Desired: `"nginx:1.25.3"`,
+			Actual:  `"nginx:hotfix"`,
+		}))
+
+		By("changing the Application spec")
+		app := getApplication()
+		app.Spec.Image = "nginx:1.26.0"
+		Expect(k8sClient.Update(ctx, app)).To(Succeed())
+		reconcileApplication()
+
+		Expect(deploymentImage()).To(Equal("nginx:1.26.0"))
+		Expect(getApplication().Status.Drift).To(BeEmpty())
+	})
+
+	It("should leave manual changes alone with the Ignore policy", func() {
+		setUp(appsv1beta1.DriftPolicyIgnore)
+		reconcileApplication()
+
+		Expect(deploymentImage()).To(Equal("nginx:hotfix"))
+		Expect(getApplication().Status.Drift).To(BeEmpty())
+	})
+
+	It("should not report fields the operator does not manage", func() {
+		setUp(appsv1beta1.DriftPolicyReport)
+
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+		dep.Spec.Template.Spec.Containers[0].Image = "nginx:1.25.3"
+		dep.Labels["team"] = "payments"
+		dep.Spec.Template.Spec.ServiceAccountName = "custom"
+		Expect(k8sClient.Update(ctx, dep)).To(Succeed())
+		reconcileApplication()
+
+		Expect(getApplication().Status.Drift).To(BeEmpty())
+	})
+})
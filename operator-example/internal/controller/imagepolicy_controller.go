@@ -0,0 +1,213 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+// defaultImagePolicyInterval is used when spec.imagePolicy.interval is not set
+const defaultImagePolicyInterval = 5 * time.Minute
+
+// ImagePolicyReconciler resolves the image policy of an Application to an
+// image pinned by digest and records it in the Application status, from
+// where ApplicationReconciler rolls it out.
+type ImagePolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// RegistryOptions are passed to every registry request, e.g. to
+	// authenticate or to use a custom transport
+	RegistryOptions []remote.Option
+}
+
+// Reconcile scans the registry of an Application with an image policy and
+// checks back after the policy interval.
+func (r *ImagePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	application := &appsv1beta1.Application{}
+	err := r.Get(ctx, req.NamespacedName, application)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get Application")
+		return ctrl.Result{}, err
+	}
+
+	policy := application.Spec.ImagePolicy
+	if policy == nil {
+		// Forget a previous resolution so the Application goes back to spec.image
+		if application.Status.ResolvedImage == "" && meta.FindStatusCondition(application.Status.Conditions, appsv1beta1.ConditionImageResolved) == nil {
+			return ctrl.Result{}, nil
+		}
+		appCopy := application.DeepCopy()
+		appCopy.Status.ResolvedImage = ""
+		appCopy.Status.ResolvedDigest = ""
+		meta.RemoveStatusCondition(&appCopy.Status.Conditions, appsv1beta1.ConditionImageResolved)
+		return ctrl.Result{}, r.Status().Patch(ctx, appCopy, client.MergeFrom(application))
+	}
+
+	interval := defaultImagePolicyInterval
+	if policy.Interval != nil && policy.Interval.Duration > 0 {
+		interval = policy.Interval.Duration
+	}
+
+	appCopy := application.DeepCopy()
+	image, digest, err := r.resolveImage(ctx, application)
+	if err != nil {
+		// Keep running the last resolved image and report why it is not updated
+		log.Info("Failed to resolve image policy", "reason", err.Error())
+		meta.SetStatusCondition(&appCopy.Status.Conditions, metav1.Condition{
+			Type:               appsv1beta1.ConditionImageResolved,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ResolutionFailed",
+			Message:            err.Error(),
+			ObservedGeneration: application.Generation,
+		})
+	} else {
+		if image != application.Status.ResolvedImage {
+			log.Info("Resolved new image", "image", image)
+		}
+		appCopy.Status.ResolvedImage = image
+		appCopy.Status.ResolvedDigest = digest
+		meta.SetStatusCondition(&appCopy.Status.Conditions, metav1.Condition{
+			Type:               appsv1beta1.ConditionImageResolved,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Resolved",
+			Message:            "Image policy resolved to " + image,
+			ObservedGeneration: application.Generation,
+		})
+	}
+
+	if err := r.Status().Patch(ctx, appCopy, client.MergeFrom(application)); err != nil {
+		log.Error(err, "Failed to update Application status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// resolveImage lists the tags of the policy repository, selects the newest
+// tag allowed by the policy and returns the image pinned by the digest of
+// that tag.
+func (r *ImagePolicyReconciler) resolveImage(ctx context.Context, app *appsv1beta1.Application) (string, string, error) {
+	policy := app.Spec.ImagePolicy
+
+	var repo name.Repository
+	if policy.Repository != "" {
+		parsed, err := name.NewRepository(policy.Repository)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid repository %q: %w", policy.Repository, err)
+		}
+		repo = parsed
+	} else {
+		ref, err := name.ParseReference(app.Spec.Image)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid image %q: %w", app.Spec.Image, err)
+		}
+		repo = ref.Context()
+	}
+
+	opts := append([]remote.Option{remote.WithContext(ctx)}, r.RegistryOptions...)
+	tags, err := remote.List(repo, opts...)
+	if err != nil {
+		return "", "", fmt.Errorf("listing tags of %s: %w", repo, err)
+	}
+	tag, err := selectTag(policy, tags)
+	if err != nil {
+		return "", "", err
+	}
+	if tag == "" {
+		return "", "", fmt.Errorf("no tag of %s matches the image policy", repo)
+	}
+
+	desc, err := remote.Head(repo.Tag(tag), opts...)
+	if err != nil {
+		return "", "", fmt.Errorf("resolving %s:%s: %w", repo, tag, err)
+	}
+	digest := desc.Digest.String()
+	return fmt.Sprintf("%s:%s@%s", repo, tag, digest), digest, nil
+}
+
+// selectTag returns the newest of tags allowed by policy, or "" when none is.
+// Semver policies pick the highest version in range, tag patterns the
+// greatest matching tag in lexical order.
+func selectTag(policy *appsv1beta1.ImagePolicy, tags []string) (string, error) {
+	if policy.Semver != "" {
+		constraint, err := semver.NewConstraint(policy.Semver)
+		if err != nil {
+			return "", fmt.Errorf("invalid semver range %q: %w", policy.Semver, err)
+		}
+		var best *semver.Version
+		var bestTag string
+		for _, tag := range tags {
+			version, err := semver.NewVersion(tag)
+			if err != nil || !constraint.Check(version) {
+				continue
+			}
+			if best == nil || version.GreaterThan(best) {
+				best, bestTag = version, tag
+			}
+		}
+		return bestTag, nil
+	}
+
+	pattern, err := regexp.Compile(policy.TagPattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag pattern %q: %w", policy.TagPattern, err)
+	}
+	var matching []string
+	for _, tag := range tags {
+		if pattern.MatchString(tag) {
+			matching = append(matching, tag)
+		}
+	}
+	if len(matching) == 0 {
+		return "", nil
+	}
+	sort.Strings(matching)
+	return matching[len(matching)-1], nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ImagePolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Only spec changes trigger a scan, the status written here must not
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("imagepolicy").
+		For(&appsv1beta1.Application{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"io"
+	stdlog "log"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+var _ = Describe("ImagePolicy Controller", func() {
+	DescribeTable("selecting the newest allowed tag",
+		func(policy appsv1beta1.ImagePolicy, tag string) {
+			tags := []string{"latest", "1.0.0", "1.2.0", "v1.10.1", "2.0.0", "2.1.0-rc.1", "main-20250102", "main-20250110"}
+			Expect(selectTag(&policy, tags)).To(Equal(tag))
+		},
+		Entry("semver range", appsv1beta1.ImagePolicy{Semver: ">=1.0.0 <2.0.0"}, "v1.10.1"),
+		Entry("semver range without pre-releases", appsv1beta1.ImagePolicy{Semver: ">=2.0.0"}, "2.0.0"),
+		Entry("semver range with pre-releases", appsv1beta1.ImagePolicy{Semver: ">=2.0.0-0"}, "2.1.0-rc.1"),
+		Entry("semver range without match", appsv1beta1.ImagePolicy{Semver: "^3"}, ""),
+		Entry("tag pattern", appsv1beta1.ImagePolicy{TagPattern: "^main-[0-9]+$"}, "main-20250110"),
+		Entry("tag pattern without match", appsv1beta1.ImagePolicy{TagPattern: "^release-"}, ""),
+	)
+
+	Context("When an Application has an image policy", func() {
+		const resourceName = "pinned-app"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		var server *httptest.Server
+		var repository string
+		digests := map[string]string{}
+
+		// pushImage pushes a random image as tag to the test registry
+		pushImage := func(tag string) {
+			img, err := random.Image(512, 1)
+			Expect(err).NotTo(HaveOccurred())
+			ref, err := name.ParseReference(repository + ":" + tag)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.Write(ref, img)).To(Succeed())
+			digest, err := img.Digest()
+			Expect(err).NotTo(HaveOccurred())
+			digests[tag] = digest.String()
+		}
+
+		reconcileApplication := func() {
+			By("resolving the image policy")
+			imagePolicyReconciler := &ImagePolicyReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := imagePolicyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("rolling out the resolved image")
+			applicationReconciler := &ApplicationReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err = applicationReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			By("starting a local registry")
+			server = httptest.NewServer(registry.New(registry.Logger(stdlog.New(io.Discard, "", 0))))
+			repository = strings.TrimPrefix(server.URL, "http://") + "/team/web"
+			for _, tag := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+				pushImage(tag)
+			}
+
+			Expect(k8sClient.Create(ctx, &appsv1beta1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: appsv1beta1.ApplicationSpec{
+					Image:       repository + ":1.0.0",
+					Replicas:    ptr.To[int32](1),
+					Ports:       []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}},
+					ImagePolicy: &appsv1beta1.ImagePolicy{Semver: "^1"},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			server.Close()
+
+			By("Cleanup the Application and its resources")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1beta1.Application{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+		})
+
+		It("should pin the newest matching tag by digest and follow new tags", func() {
+			reconcileApplication()
+
+			app := &appsv1beta1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, app)).To(Succeed())
+			pinned := repository + ":1.1.0@" + digests["1.1.0"]
+			Expect(app.Status.ResolvedImage).To(Equal(pinned))
+			Expect(app.Status.ResolvedDigest).To(Equal(digests["1.1.0"]))
+			Expect(meta.IsStatusConditionTrue(app.Status.Conditions, appsv1beta1.ConditionImageResolved)).To(BeTrue())
+
+			dep := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+			Expect(dep.Spec.Template.Spec.Containers[0].Image).To(Equal(pinned))
+			Expect(dep.Labels).To(HaveKeyWithValue("app.kubernetes.io/version", "1.1.0"))
+
+			By("pushing a newer tag within the range")
+			pushImage("1.2.0")
+			reconcileApplication()
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, dep)).To(Succeed())
+			Expect(dep.Spec.Template.Spec.Containers[0].Image).To(Equal(repository + ":1.2.0@" + digests["1.2.0"]))
+		})
+
+		It("should keep the last resolved image when the registry is unreachable", func() {
+			reconcileApplication()
+			server.Close()
+			reconcileApplication()
+
+			app := &appsv1beta1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, app)).To(Succeed())
+			Expect(app.Status.ResolvedImage).To(Equal(repository + ":1.1.0@" + digests["1.1.0"]))
+			condition := meta.FindStatusCondition(app.Status.Conditions, appsv1beta1.ConditionImageResolved)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		})
+	})
+})
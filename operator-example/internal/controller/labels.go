@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+const (
+	labelName      = "app.kubernetes.io/name"
+	labelInstance  = "app.kubernetes.io/instance"
+	labelVersion   = "app.kubernetes.io/version"
+	labelManagedBy = "app.kubernetes.io/managed-by"
+
+	// managedByValue identifies this operator in the managed-by label
+	managedByValue = "operator-example"
+)
+
+// selectorLabels returns the labels used to select the pods of an Application.
+// They are part of the immutable Deployment selector, so they must never change
+// for an existing Application.
+func selectorLabels(app *appsv1beta1.Application) map[string]string {
+	return map[string]string{
+		"app": app.Name,
+	}
+}
+
+// standardLabels returns the selector labels plus the recommended
+// app.kubernetes.io label set applied to every object owned by app.
+func standardLabels(app *appsv1beta1.Application) map[string]string {
+	labels := selectorLabels(app)
+	labels[labelName] = app.Name
+	if name := imageName(containerImage(app)); name != "" {
+		labels[labelName] = name
+	}
+	labels[labelInstance] = app.Name
+	labels[labelManagedBy] = managedByValue
+	if version := imageVersion(containerImage(app)); version != "" {
+		labels[labelVersion] = version
+	}
+	return labels
+}
+
+// podLabels returns the labels for the pod template of app. User supplied pod
+// labels are applied first so they can never override the standard labels or
+// the selector.
+func podLabels(app *appsv1beta1.Application) map[string]string {
+	return mergeMaps(app.Spec.PodLabels, standardLabels(app))
+}
+
+// imageName derives the name of the application from the last path segment
+// of the image repository, e.g. "nginx" for docker.io/library/nginx:1.25. It
+// returns "" when that is not a valid label value.
+func imageName(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if len(validation.IsValidLabelValue(name)) > 0 {
+		return ""
+	}
+	return name
+}
+
+// imageVersion derives a label-safe version from the tag of image. It returns
+// "latest" for untagged images and "" when the tag is not a valid label value.
+func imageVersion(image string) string {
+	// Drop the digest, the tag is what people recognise as a version
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	version := "latest"
+	// A colon before the last slash belongs to the registry host:port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		version = image[i+1:]
+	}
+	if len(validation.IsValidLabelValue(version)) > 0 {
+		return ""
+	}
+	return version
+}
+
+// mergeMaps returns a new map containing the entries of all maps, later maps
+// taking precedence. It returns nil when the result would be empty.
+func mergeMaps(maps ...map[string]string) map[string]string {
+	var out map[string]string
+	for _, m := range maps {
+		for k, v := range m {
+			if out == nil {
+				out = map[string]string{}
+			}
+			out[k] = v
+		}
+	}
+	return out
+}
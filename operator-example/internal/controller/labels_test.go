@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+var _ = Describe("Owned object labels", func() {
+	DescribeTable("deriving the version label from the image",
+		func(image, version string) {
+			Expect(imageVersion(image)).To(Equal(version))
+		},
+		Entry("tagged image", "nginx:1.25.3", "1.25.3"),
+		Entry("untagged image", "nginx", "latest"),
+		Entry("registry with port", "localhost:5000/team/nginx", "latest"),
+		Entry("registry with port and tag", "localhost:5000/team/nginx:v2", "v2"),
+		Entry("tag and digest", "nginx:1.25@sha256:0123456789abcdef", "1.25"),
+		Entry("tag that is not a valid label value", "nginx:"+strings.Repeat("a", 64), ""),
+	)
+
+	DescribeTable("deriving the name label from the image",
+		func(image, name string) {
+			Expect(imageName(image)).To(Equal(name))
+		},
+		Entry("tagged image", "nginx:1.25.3", "nginx"),
+		Entry("registry with port", "localhost:5000/team/web", "web"),
+		Entry("tag and digest", "docker.io/library/nginx:1.25@sha256:0123456789abcdef", "nginx"),
+		Entry("name that is not a valid label value", strings.Repeat("a", 64)+":1.0", ""),
+	)
+
+	It("should propagate labels and annotations without touching the selector", func() {
+		app := &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "labelled", Namespace: "default"},
+			Spec: appsv1beta1.ApplicationSpec{
+				Image:    "nginx:1.25.3",
+				Replicas: ptr.To[int32](1),
+				Ports:    []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+				},
+				PodLabels:          map[string]string{"team": "payments", "app": "hijacked"},
+				PodAnnotations:     map[string]string{"prometheus.io/scrape": "true"},
+				ServiceAnnotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+			},
+		}
+		r := &ApplicationReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+		dep := r.deploymentForApplication(app)
+		Expect(dep.Spec.Selector.MatchLabels).To(Equal(map[string]string{"app": "labelled"}))
+		Expect(dep.Labels).To(HaveKeyWithValue("app.kubernetes.io/version", "1.25.3"))
+		Expect(dep.Labels).To(HaveKeyWithValue("app.kubernetes.io/managed-by", "operator-example"))
+		Expect(dep.Spec.Template.Labels).To(HaveKeyWithValue("app", "labelled"))
+		Expect(dep.Spec.Template.Labels).To(HaveKeyWithValue("team", "payments"))
+		Expect(dep.Spec.Template.Labels).To(HaveKeyWithValue("app.kubernetes.io/instance", "labelled"))
+		Expect(dep.Spec.Template.Annotations).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+
+		svc := r.serviceForApplication(app)
+		Expect(svc.Spec.Selector).To(Equal(map[string]string{"app": "labelled"}))
+		Expect(svc.Labels).To(HaveKeyWithValue("app.kubernetes.io/name", "nginx"))
+		Expect(svc.Labels).To(HaveKeyWithValue("app.kubernetes.io/instance", "labelled"))
+		Expect(svc.Annotations).To(HaveKeyWithValue("service.beta.kubernetes.io/aws-load-balancer-internal", "true"))
+	})
+})
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	appsv1alpha1 "github.com/liweinan/k8s-example/operator-example/api/v1alpha1"
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+// RenderApplication returns the objects the operator creates for app, built
+// exactly as Reconcile builds them but without talking to a cluster. The
+// defaults of the Application CRD are applied first, since app has usually
+// not been through the API server. The objects have their TypeMeta set so
+// they can be printed as manifests.
+func RenderApplication(scheme *runtime.Scheme, app *appsv1beta1.Application) ([]client.Object, error) {
+	app = app.DeepCopy()
+	defaultApplication(app)
+
+	r := &ApplicationReconciler{Scheme: scheme}
+	objects := []client.Object{
+		r.deploymentForApplication(app),
+		r.serviceForApplication(app),
+	}
+	for _, obj := range objects {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	return objects, nil
+}
+
+// RenderV1alpha1Application is RenderApplication for a v1alpha1 Application.
+// The defaults of the v1alpha1 CRD are applied before app is converted, as
+// the API server does when the Application is created. The API server only
+// defaults the fields of spec.resources when spec.resources is set, which app
+// cannot tell, so withResources says whether the manifest set it.
+func RenderV1alpha1Application(scheme *runtime.Scheme, app *appsv1alpha1.Application, withResources bool) ([]client.Object, error) {
+	app = app.DeepCopy()
+	defaultV1alpha1Application(app, withResources)
+	hub := &appsv1beta1.Application{}
+	if err := app.ConvertTo(hub); err != nil {
+		return nil, fmt.Errorf("converting Application %s: %w", app.Name, err)
+	}
+	return RenderApplication(scheme, hub)
+}
+
+// defaultV1alpha1Application applies the defaults declared by the kubebuilder
+// markers of the v1alpha1 Application CRD.
+func defaultV1alpha1Application(app *appsv1alpha1.Application, withResources bool) {
+	if app.Spec.Replicas == nil {
+		app.Spec.Replicas = ptr.To[int32](1)
+	}
+	if app.Spec.Port == 0 {
+		app.Spec.Port = 80
+	}
+	if !withResources {
+		return
+	}
+	for _, r := range []struct {
+		value *string
+		def   string
+	}{
+		{&app.Spec.Resources.CPURequest, "100m"},
+		{&app.Spec.Resources.MemoryRequest, "128Mi"},
+		{&app.Spec.Resources.CPULimit, "200m"},
+		{&app.Spec.Resources.MemoryLimit, "256Mi"},
+	} {
+		if *r.value == "" {
+			*r.value = r.def
+		}
+	}
+}
+
+// defaultApplication applies the defaults declared by the kubebuilder markers
+// of the Application CRD.
+func defaultApplication(app *appsv1beta1.Application) {
+	if app.Spec.Replicas == nil {
+		app.Spec.Replicas = ptr.To[int32](1)
+	}
+	if app.Spec.Ports == nil {
+		app.Spec.Ports = []appsv1beta1.ApplicationPort{{Name: "http", ContainerPort: 80}}
+	}
+	if app.Spec.DriftPolicy == "" {
+		app.Spec.DriftPolicy = appsv1beta1.DriftPolicyRevert
+	}
+	if app.Namespace == "" {
+		app.Namespace = "default"
+	}
+}
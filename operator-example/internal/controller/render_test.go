@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+// TestRenderApplication runs without the envtest control plane, since
+// rendering never talks to a cluster.
+func TestRenderApplication(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appsv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &appsv1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "rendered"},
+		Spec:       appsv1beta1.ApplicationSpec{Image: "nginx:1.25.3"},
+	}
+	objects, err := RenderApplication(scheme, app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("got %d objects, want 2", len(objects))
+	}
+	if app.Spec.Replicas != nil || app.Spec.Ports != nil {
+		t.Errorf("the input must not be modified, got %+v", app.Spec)
+	}
+
+	dep, ok := objects[0].(*appsv1.Deployment)
+	if !ok {
+		t.Fatalf("first object is a %T, want a Deployment", objects[0])
+	}
+	if dep.APIVersion != "apps/v1" || dep.Kind != "Deployment" {
+		t.Errorf("Deployment has TypeMeta %s %s", dep.APIVersion, dep.Kind)
+	}
+	if dep.Namespace != "default" {
+		t.Errorf("namespace = %q, want default", dep.Namespace)
+	}
+	if dep.Spec.Replicas == nil || *dep.Spec.Replicas != 1 {
+		t.Errorf("replicas = %v, want the CRD default 1", dep.Spec.Replicas)
+	}
+	want := corev1.ContainerPort{Name: "http", ContainerPort: 80, Protocol: corev1.ProtocolTCP}
+	if ports := dep.Spec.Template.Spec.Containers[0].Ports; len(ports) != 1 || ports[0] != want {
+		t.Errorf("container ports = %v, want the CRD default %v", ports, want)
+	}
+	if len(dep.OwnerReferences) != 1 || dep.OwnerReferences[0].Kind != "Application" {
+		t.Errorf("owner references = %v, want the Application", dep.OwnerReferences)
+	}
+
+	svc, ok := objects[1].(*corev1.Service)
+	if !ok {
+		t.Fatalf("second object is a %T, want a Service", objects[1])
+	}
+	if svc.APIVersion != "v1" || svc.Kind != "Service" {
+		t.Errorf("Service has TypeMeta %s %s", svc.APIVersion, svc.Kind)
+	}
+	if len(svc.Spec.Ports) != 1 {
+		t.Errorf("service ports = %v, want one port", svc.Spec.Ports)
+	}
+}
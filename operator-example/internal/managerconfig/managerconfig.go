@@ -0,0 +1,334 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package managerconfig defines the versioned configuration file of the
+// operator manager, passed with --config.
+package managerconfig
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion of the configuration file format
+	APIVersion = "config.apps.example.com/v1alpha1"
+	// Kind of the configuration file
+	Kind = "ManagerConfig"
+)
+
+// Feature gates
+const (
+	// ApplicationSet runs the ApplicationSet controller
+	ApplicationSet = "ApplicationSet"
+	// ImagePolicy runs the controller resolving spec.imagePolicy of Applications
+	ImagePolicy = "ImagePolicy"
+)
+
+// defaultFeatureGates lists every known feature gate with its default
+var defaultFeatureGates = map[string]bool{
+	ApplicationSet: true,
+	ImagePolicy:    true,
+}
+
+// ManagerConfig is the configuration of the operator manager. Only
+// controller.requeueInterval and controller.requeueJitter are reloaded while
+// the operator runs, see Watcher;
+// changes to any other field are logged as errors and take effect after a
+// restart.
+type ManagerConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Metrics configures the metrics endpoint
+	Metrics Metrics `json:"metrics,omitempty"`
+
+	// Health configures the health probe endpoint
+	Health Health `json:"health,omitempty"`
+
+	// LeaderElection configures leader election between replicas
+	LeaderElection LeaderElection `json:"leaderElection,omitempty"`
+
+	// Controller configures the Application controller
+	Controller Controller `json:"controller,omitempty"`
+
+	// WatchNamespaces restricts the operator to these namespaces.
+	// All namespaces are watched if empty.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+
+	// FeatureGates enables or disables optional features by name
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// Metrics configures the metrics endpoint.
+type Metrics struct {
+	// BindAddress is the address the metrics endpoint binds to, "0" disables it
+	BindAddress string `json:"bindAddress,omitempty"`
+
+	// Secure serves the metrics endpoint over HTTPS
+	Secure bool `json:"secure,omitempty"`
+}
+
+// Health configures the health probe endpoint.
+type Health struct {
+	// ProbeBindAddress is the address the health probes bind to, "0" disables them
+	ProbeBindAddress string `json:"probeBindAddress,omitempty"`
+}
+
+// LeaderElection configures leader election between replicas.
+type LeaderElection struct {
+	// LeaderElect enables leader election
+	LeaderElect bool `json:"leaderElect,omitempty"`
+
+	// ResourceName is the name of the Lease used for leader election
+	ResourceName string `json:"resourceName,omitempty"`
+
+	// LeaseDuration is how long non-leaders wait before taking over the lease
+	LeaseDuration metav1.Duration `json:"leaseDuration,omitempty"`
+
+	// RenewDeadline is how long the leader retries renewing the lease
+	RenewDeadline metav1.Duration `json:"renewDeadline,omitempty"`
+
+	// RetryPeriod is how long clients wait between attempts
+	RetryPeriod metav1.Duration `json:"retryPeriod,omitempty"`
+}
+
+// Controller configures the Application controller.
+type Controller struct {
+	// MaxConcurrentReconciles is the number of Applications reconciled in parallel
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles,omitempty"`
+
+	// RequeueInterval is how often Applications are checked again when
+	// nothing else triggers them. It is reloaded without a restart.
+	RequeueInterval metav1.Duration `json:"requeueInterval,omitempty"`
+
+	// RequeueJitter adds a random delay of up to this fraction of
+	// requeueInterval to every periodic check, between 0 and 1. It is
+	// reloaded without a restart.
+	RequeueJitter float64 `json:"requeueJitter,omitempty"`
+
+	// RateLimiter limits how fast Applications are retried after errors
+	RateLimiter RateLimiter `json:"rateLimiter,omitempty"`
+}
+
+// RateLimiter combines a per Application exponential backoff with an
+// overall token bucket.
+type RateLimiter struct {
+	// BaseDelay is the delay before the first retry of an Application
+	BaseDelay metav1.Duration `json:"baseDelay,omitempty"`
+
+	// MaxDelay caps the delay, which doubles on every failure
+	MaxDelay metav1.Duration `json:"maxDelay,omitempty"`
+
+	// QPS is the number of requests processed per second overall
+	QPS int `json:"qps,omitempty"`
+
+	// Burst is the number of requests processed at once above QPS
+	Burst int `json:"burst,omitempty"`
+}
+
+// Default returns the configuration used for fields missing from the file.
+func Default() *ManagerConfig {
+	cfg := &ManagerConfig{
+		TypeMeta: metav1.TypeMeta{APIVersion: APIVersion, Kind: Kind},
+		Metrics:  Metrics{BindAddress: ":8080"},
+		Health:   Health{ProbeBindAddress: ":8081"},
+		LeaderElection: LeaderElection{
+			ResourceName:  "b84ea92d.example.com",
+			LeaseDuration: metav1.Duration{Duration: 15 * time.Second},
+			RenewDeadline: metav1.Duration{Duration: 10 * time.Second},
+			RetryPeriod:   metav1.Duration{Duration: 2 * time.Second},
+		},
+		Controller: Controller{
+			MaxConcurrentReconciles: 1,
+			RequeueInterval:         metav1.Duration{Duration: time.Minute},
+			RequeueJitter:           0.1,
+			// Same as the controller-runtime default
+			RateLimiter: RateLimiter{
+				BaseDelay: metav1.Duration{Duration: 5 * time.Millisecond},
+				MaxDelay:  metav1.Duration{Duration: 1000 * time.Second},
+				QPS:       10,
+				Burst:     100,
+			},
+		},
+		FeatureGates: map[string]bool{},
+	}
+	for gate, enabled := range defaultFeatureGates {
+		cfg.FeatureGates[gate] = enabled
+	}
+	return cfg
+}
+
+// Load reads and validates the configuration file at path. Fields missing
+// from the file keep their defaults and unknown fields are rejected.
+func Load(path string) (*ManagerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes and validates a configuration file.
+func Parse(data []byte) (*ManagerConfig, error) {
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal(data, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.APIVersion != APIVersion || typeMeta.Kind != Kind {
+		return nil, fmt.Errorf("unsupported configuration %s %s, expected %s %s",
+			typeMeta.APIVersion, typeMeta.Kind, APIVersion, Kind)
+	}
+
+	cfg := Default()
+	gates := cfg.FeatureGates
+	cfg.FeatureGates = nil
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, err
+	}
+	// Gates missing from the file keep their defaults
+	for gate, enabled := range cfg.FeatureGates {
+		gates[gate] = enabled
+	}
+	cfg.FeatureGates = gates
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration for values the manager cannot start with.
+func (c *ManagerConfig) Validate() error {
+	var errs field.ErrorList
+
+	errs = append(errs, validateAddress(field.NewPath("metrics", "bindAddress"), c.Metrics.BindAddress)...)
+	errs = append(errs, validateAddress(field.NewPath("health", "probeBindAddress"), c.Health.ProbeBindAddress)...)
+
+	le := field.NewPath("leaderElection")
+	if c.LeaderElection.ResourceName == "" {
+		errs = append(errs, field.Required(le.Child("resourceName"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(c.LeaderElection.ResourceName) {
+			errs = append(errs, field.Invalid(le.Child("resourceName"), c.LeaderElection.ResourceName, msg))
+		}
+	}
+	for name, d := range map[string]time.Duration{
+		"leaseDuration": c.LeaderElection.LeaseDuration.Duration,
+		"renewDeadline": c.LeaderElection.RenewDeadline.Duration,
+		"retryPeriod":   c.LeaderElection.RetryPeriod.Duration,
+	} {
+		if d <= 0 {
+			errs = append(errs, field.Invalid(le.Child(name), d.String(), "must be positive"))
+		}
+	}
+	// Same constraints as client-go leader election
+	if c.LeaderElection.LeaseDuration.Duration <= c.LeaderElection.RenewDeadline.Duration {
+		errs = append(errs, field.Invalid(le.Child("leaseDuration"), c.LeaderElection.LeaseDuration.Duration.String(),
+			"must be greater than renewDeadline"))
+	}
+	if c.LeaderElection.RenewDeadline.Duration <= c.LeaderElection.RetryPeriod.Duration*6/5 {
+		errs = append(errs, field.Invalid(le.Child("renewDeadline"), c.LeaderElection.RenewDeadline.Duration.String(),
+			"must be greater than 1.2 times retryPeriod"))
+	}
+
+	ctrlPath := field.NewPath("controller")
+	if c.Controller.MaxConcurrentReconciles < 1 {
+		errs = append(errs, field.Invalid(ctrlPath.Child("maxConcurrentReconciles"), c.Controller.MaxConcurrentReconciles,
+			"must be at least 1"))
+	}
+	if c.Controller.RequeueInterval.Duration < time.Second {
+		errs = append(errs, field.Invalid(ctrlPath.Child("requeueInterval"), c.Controller.RequeueInterval.Duration.String(),
+			"must be at least 1s"))
+	}
+	if c.Controller.RequeueJitter < 0 || c.Controller.RequeueJitter > 1 {
+		errs = append(errs, field.Invalid(ctrlPath.Child("requeueJitter"), c.Controller.RequeueJitter,
+			"must be between 0 and 1"))
+	}
+	rl := ctrlPath.Child("rateLimiter")
+	if c.Controller.RateLimiter.BaseDelay.Duration <= 0 {
+		errs = append(errs, field.Invalid(rl.Child("baseDelay"), c.Controller.RateLimiter.BaseDelay.Duration.String(),
+			"must be positive"))
+	}
+	if c.Controller.RateLimiter.MaxDelay.Duration < c.Controller.RateLimiter.BaseDelay.Duration {
+		errs = append(errs, field.Invalid(rl.Child("maxDelay"), c.Controller.RateLimiter.MaxDelay.Duration.String(),
+			"must not be less than baseDelay"))
+	}
+	if c.Controller.RateLimiter.QPS < 1 {
+		errs = append(errs, field.Invalid(rl.Child("qps"), c.Controller.RateLimiter.QPS, "must be at least 1"))
+	}
+	if c.Controller.RateLimiter.Burst < 1 {
+		errs = append(errs, field.Invalid(rl.Child("burst"), c.Controller.RateLimiter.Burst, "must be at least 1"))
+	}
+
+	for i, namespace := range c.WatchNamespaces {
+		for _, msg := range validation.IsDNS1123Label(namespace) {
+			errs = append(errs, field.Invalid(field.NewPath("watchNamespaces").Index(i), namespace, msg))
+		}
+	}
+
+	for _, gate := range sortedKeys(c.FeatureGates) {
+		if _, ok := defaultFeatureGates[gate]; !ok {
+			errs = append(errs, field.NotSupported(field.NewPath("featureGates").Key(gate), gate, sortedKeys(defaultFeatureGates)))
+		}
+	}
+
+	return errs.ToAggregate()
+}
+
+// Enabled reports whether the feature gate is enabled.
+func (c *ManagerConfig) Enabled(gate string) bool {
+	if enabled, ok := c.FeatureGates[gate]; ok {
+		return enabled
+	}
+	return defaultFeatureGates[gate]
+}
+
+// validateAddress accepts host:port addresses and "0", which disables the endpoint
+func validateAddress(path *field.Path, address string) field.ErrorList {
+	if address == "0" {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, address, err.Error())}
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return field.ErrorList{field.Invalid(path, address, "invalid port")}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managerconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const header = "apiVersion: config.apps.example.com/v1alpha1\nkind: ManagerConfig\n"
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(header + `
+metrics:
+  bindAddress: 127.0.0.1:8080
+leaderElection:
+  leaderElect: true
+  leaseDuration: 30s
+controller:
+  maxConcurrentReconciles: 4
+  requeueInterval: 5m
+  rateLimiter:
+    maxDelay: 5m
+watchNamespaces: [team-a, team-b]
+featureGates:
+  ImagePolicy: false
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Metrics.BindAddress != "127.0.0.1:8080" || cfg.Health.ProbeBindAddress != ":8081" {
+		t.Errorf("addresses = %q, %q", cfg.Metrics.BindAddress, cfg.Health.ProbeBindAddress)
+	}
+	if !cfg.LeaderElection.LeaderElect || cfg.LeaderElection.LeaseDuration.Duration != 30*time.Second ||
+		cfg.LeaderElection.RenewDeadline.Duration != 10*time.Second {
+		t.Errorf("leader election = %+v", cfg.LeaderElection)
+	}
+	if cfg.Controller.MaxConcurrentReconciles != 4 || cfg.Controller.RequeueInterval.Duration != 5*time.Minute ||
+		cfg.Controller.RequeueJitter != 0.1 {
+		t.Errorf("controller = %+v", cfg.Controller)
+	}
+	if cfg.Controller.RateLimiter.MaxDelay.Duration != 5*time.Minute || cfg.Controller.RateLimiter.BaseDelay.Duration != 5*time.Millisecond {
+		t.Errorf("rate limiter = %+v", cfg.Controller.RateLimiter)
+	}
+	if len(cfg.WatchNamespaces) != 2 {
+		t.Errorf("watchNamespaces = %v", cfg.WatchNamespaces)
+	}
+	if cfg.Enabled(ImagePolicy) || !cfg.Enabled(ApplicationSet) {
+		t.Errorf("feature gates = %v", cfg.FeatureGates)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "missing version",
+			data:    "metrics: {}\n",
+			wantErr: "unsupported configuration",
+		},
+		{
+			name:    "unknown version",
+			data:    "apiVersion: config.apps.example.com/v2\nkind: ManagerConfig\n",
+			wantErr: "unsupported configuration",
+		},
+		{
+			name:    "unknown field",
+			data:    header + "controller:\n  workers: 2\n",
+			wantErr: "unknown field",
+		},
+		{
+			name:    "invalid address",
+			data:    header + "metrics:\n  bindAddress: localhost\n",
+			wantErr: "metrics.bindAddress",
+		},
+		{
+			name:    "lease shorter than renew deadline",
+			data:    header + "leaderElection:\n  leaseDuration: 5s\n",
+			wantErr: "leaderElection.leaseDuration",
+		},
+		{
+			name:    "no workers",
+			data:    header + "controller:\n  maxConcurrentReconciles: -1\n",
+			wantErr: "controller.maxConcurrentReconciles",
+		},
+		{
+			name:    "requeue too often",
+			data:    header + "controller:\n  requeueInterval: 10ms\n",
+			wantErr: "controller.requeueInterval",
+		},
+		{
+			name:    "jitter above 1",
+			data:    header + "controller:\n  requeueJitter: 1.5\n",
+			wantErr: "controller.requeueJitter",
+		},
+		{
+			name:    "max delay below base delay",
+			data:    header + "controller:\n  rateLimiter:\n    baseDelay: 1s\n    maxDelay: 10ms\n",
+			wantErr: "controller.rateLimiter.maxDelay",
+		},
+		{
+			name:    "no qps",
+			data:    header + "controller:\n  rateLimiter:\n    qps: -1\n",
+			wantErr: "controller.rateLimiter.qps",
+		},
+		{
+			name:    "invalid namespace",
+			data:    header + "watchNamespaces: [Team_A]\n",
+			wantErr: "watchNamespaces[0]",
+		},
+		{
+			name:    "unknown feature gate",
+			data:    header + "featureGates:\n  Teleport: true\n",
+			wantErr: "featureGates[Teleport]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(header+data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("controller:\n  requeueInterval: 1m\n")
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := *loaded
+	// Overridden on the command line
+	current.Metrics.BindAddress = "0"
+	w, err := NewWatcher(path, loaded, &current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged []string
+	ctx := log.IntoContext(context.Background(), funcr.New(func(prefix, args string) {
+		logged = append(logged, args)
+	}, funcr.Options{}))
+
+	write("controller:\n  requeueInterval: 2m\n  requeueJitter: 0.3\n  maxConcurrentReconciles: 8\n")
+	w.reload(ctx)
+	if got := w.RequeueInterval(); got != 2*time.Minute {
+		t.Errorf("requeue interval = %v, want 2m", got)
+	}
+	if got := w.RequeueJitter(); got != 0.3 {
+		t.Errorf("requeue jitter = %v, want 0.3", got)
+	}
+	if all := strings.Join(logged, "\n"); !strings.Contains(all, `"error"="controller.maxConcurrentReconciles cannot be changed at runtime"`) {
+		t.Errorf("the ignored change was not logged as an error:\n%s", all)
+	}
+	if got := w.Config().Controller.MaxConcurrentReconciles; got != 1 {
+		t.Errorf("maxConcurrentReconciles = %d, want the value in use until a restart", got)
+	}
+	if got := w.Config().Metrics.BindAddress; got != "0" {
+		t.Errorf("metrics address = %q, want the command line value", got)
+	}
+
+	write("controller:\n  requeueInterval: 1ms\n")
+	w.reload(ctx)
+	if got := w.RequeueInterval(); got != 2*time.Minute {
+		t.Errorf("requeue interval = %v, want the last valid value", got)
+	}
+	if got := w.RequeueJitter(); got != 0.3 {
+		t.Errorf("requeue jitter = %v, want the last valid value", got)
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	old := Default()
+	updated := Default()
+	updated.Controller.RequeueInterval.Duration = time.Hour
+	updated.Controller.RequeueJitter = 0.5
+	if changed := restartRequired(old, updated); len(changed) != 0 {
+		t.Errorf("restartRequired = %v, want nothing", changed)
+	}
+
+	updated.LeaderElection.LeaseDuration.Duration = time.Minute
+	updated.Controller.RateLimiter.QPS = 1
+	updated.WatchNamespaces = []string{"team-a"}
+	updated.FeatureGates[ImagePolicy] = false
+	changed := restartRequired(old, updated)
+	want := "leaderElection.leaseDuration,controller.rateLimiter.qps,watchNamespaces,featureGates"
+	if strings.Join(changed, ",") != want {
+		t.Errorf("restartRequired = %v, want %s", changed, want)
+	}
+}
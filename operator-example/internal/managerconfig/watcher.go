@@ -0,0 +1,181 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managerconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// pollInterval is how often the configuration file is checked for changes.
+// Files mounted from a ConfigMap are replaced atomically, so polling is more
+// reliable than file system notifications.
+const pollInterval = 10 * time.Second
+
+// Watcher reloads the configuration file when it changes. Only
+// controller.requeueInterval and controller.requeueJitter are applied at
+// runtime; changes to the other
+// fields are logged as errors and take effect after a restart. Watcher implements
+// manager.Runnable.
+type Watcher struct {
+	path     string
+	interval time.Duration
+
+	// file is the last valid content of the file
+	file []byte
+	// loaded is the configuration read from file, before command line flags
+	loaded *ManagerConfig
+	// current is the configuration in use
+	current atomic.Pointer[ManagerConfig]
+}
+
+// NewWatcher returns a Watcher of the file at path, which was loaded as
+// loaded and is in use as current after applying command line flags.
+func NewWatcher(path string, loaded, current *ManagerConfig) (*Watcher, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{path: path, interval: pollInterval, file: file, loaded: loaded}
+	w.current.Store(current)
+	return w, nil
+}
+
+// Config returns the configuration in use. It must not be modified.
+func (w *Watcher) Config() *ManagerConfig {
+	return w.current.Load()
+}
+
+// RequeueInterval returns the requeue interval in use.
+func (w *Watcher) RequeueInterval() time.Duration {
+	return w.Config().Controller.RequeueInterval.Duration
+}
+
+// RequeueJitter returns the requeue jitter in use.
+func (w *Watcher) RequeueJitter() float64 {
+	return w.Config().Controller.RequeueJitter
+}
+
+// Start polls the file until ctx is done.
+func (w *Watcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.reload(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection makes every replica reload its configuration.
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// reload applies the reloadable fields of the file if it changed and is valid
+func (w *Watcher) reload(ctx context.Context) {
+	log := log.FromContext(ctx).WithName("config")
+
+	file, err := os.ReadFile(w.path)
+	if err != nil {
+		log.Error(err, "Failed to read configuration file", "path", w.path)
+		return
+	}
+	if bytes.Equal(file, w.file) {
+		return
+	}
+	loaded, err := Parse(file)
+	if err != nil {
+		log.Error(err, "Ignoring invalid configuration file", "path", w.path)
+		return
+	}
+	w.file = file
+
+	if changed := restartRequired(w.loaded, loaded); len(changed) > 0 {
+		err := fmt.Errorf("%s cannot be changed at runtime", strings.Join(changed, ", "))
+		log.Error(err, "Configuration changes ignored until the operator restarts", "path", w.path, "fields", changed)
+	}
+	w.loaded = loaded
+
+	current := *w.Config()
+	var reloaded []interface{}
+	if current.Controller.RequeueInterval != loaded.Controller.RequeueInterval {
+		current.Controller.RequeueInterval = loaded.Controller.RequeueInterval
+		reloaded = append(reloaded, "controller.requeueInterval", loaded.Controller.RequeueInterval.Duration.String())
+	}
+	if current.Controller.RequeueJitter != loaded.Controller.RequeueJitter {
+		current.Controller.RequeueJitter = loaded.Controller.RequeueJitter
+		reloaded = append(reloaded, "controller.requeueJitter", loaded.Controller.RequeueJitter)
+	}
+	if len(reloaded) > 0 {
+		log.Info("Reloaded configuration", append([]interface{}{"path", w.path}, reloaded...)...)
+		w.current.Store(&current)
+	}
+}
+
+// reloadable lists the fields applied at runtime by the Watcher
+var reloadable = map[string]bool{
+	"controller.requeueInterval": true,
+	"controller.requeueJitter":   true,
+}
+
+// restartRequired returns the fields that differ between old and updated and
+// cannot be changed at runtime, named by their path in the file. Every field
+// not listed in reloadable is compared, so new fields are covered as well.
+func restartRequired(old, updated *ManagerConfig) []string {
+	var changed []string
+	pkgPath := reflect.TypeOf(*old).PkgPath()
+	var walk func(prefix string, old, updated reflect.Value)
+	walk = func(prefix string, old, updated reflect.Value) {
+		for i := 0; i < old.NumField(); i++ {
+			field := old.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if field.Anonymous || name == "" || name == "-" {
+				continue
+			}
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			if reloadable[name] {
+				continue
+			}
+			o, u := old.Field(i), updated.Field(i)
+			// Descend into the sections of the file, not into values like durations
+			if o.Kind() == reflect.Struct && field.Type.PkgPath() == pkgPath {
+				walk(name, o, u)
+				continue
+			}
+			if !equality.Semantic.DeepEqual(o.Interface(), u.Interface()) {
+				changed = append(changed, name)
+			}
+		}
+	}
+	walk("", reflect.ValueOf(*old), reflect.ValueOf(*updated))
+	return changed
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubeconfig loads the client configuration of a Kubernetes cluster
+// in-process, from the same places kubectl, the Canonical Kubernetes (k8s)
+// snap and MicroK8s keep it.
+package kubeconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Options select the configuration to load. Empty options fall back to the
+// rest of the chain described at Load.
+type Options struct {
+	// Kubeconfig is the path of a kubeconfig file, usually from --kubeconfig
+	Kubeconfig string
+
+	// Context is the kubeconfig context to use instead of the current
+	// context, usually from --context
+	Context string
+}
+
+// Source describes where a configuration was loaded from.
+type Source struct {
+	// Name of the step of the chain, e.g. "--kubeconfig" or "in-cluster"
+	Name string
+
+	// Paths are the kubeconfig files that were loaded, empty in-cluster
+	Paths []string
+
+	// Context is the kubeconfig context that was used
+	Context string
+}
+
+func (s Source) String() string {
+	if len(s.Paths) == 0 {
+		return s.Name
+	}
+	return fmt.Sprintf("%s (%s, context %q)", s.Name, strings.Join(s.Paths, string(filepath.ListSeparator)), s.Context)
+}
+
+// snapPaths are the admin kubeconfig files written by Kubernetes
+// distributions installed as snaps. They are usually only readable by root.
+var snapPaths = []string{
+	// Canonical Kubernetes, the k8s snap (and kubeadm)
+	"/etc/kubernetes/admin.conf",
+	// MicroK8s
+	"/var/snap/microk8s/current/credentials/client.config",
+}
+
+// Load returns the first configuration found in this chain:
+//
+//  1. the kubeconfig file in opts.Kubeconfig
+//  2. the kubeconfig files listed in $KUBECONFIG
+//  3. the service account of the pod when running in a cluster
+//  4. $HOME/.kube/config
+//  5. the admin kubeconfig of the k8s snap or of MicroK8s
+//
+// Files are read in-process and nothing is written to disk. An explicit
+// kubeconfig that cannot be loaded is an error rather than a reason to move
+// on. When nothing is found, the error lists why every step was skipped.
+func Load(opts Options) (*rest.Config, Source, error) {
+	return loader{
+		getenv:    os.Getenv,
+		inCluster: rest.InClusterConfig,
+		snapPaths: snapPaths,
+	}.load(opts)
+}
+
+// loader implements Load with replaceable environment for tests
+type loader struct {
+	getenv    func(string) string
+	inCluster func() (*rest.Config, error)
+	snapPaths []string
+}
+
+func (l loader) load(opts Options) (*rest.Config, Source, error) {
+	var skipped []string
+
+	if opts.Kubeconfig != "" {
+		return loadFiles("--kubeconfig", []string{opts.Kubeconfig}, opts.Context)
+	}
+	skipped = append(skipped, "--kubeconfig: not set")
+
+	if env := l.getenv(clientcmd.RecommendedConfigPathEnvVar); env != "" {
+		var existing []string
+		for _, path := range filepath.SplitList(env) {
+			if path == "" {
+				continue
+			}
+			if err := readable(path); err != nil {
+				skipped = append(skipped, "$KUBECONFIG: "+err.Error())
+				continue
+			}
+			existing = append(existing, path)
+		}
+		if len(existing) > 0 {
+			return loadFiles("$KUBECONFIG", existing, opts.Context)
+		}
+	} else {
+		skipped = append(skipped, "$KUBECONFIG: not set")
+	}
+
+	// A context only exists in kubeconfig files
+	if opts.Context == "" {
+		config, err := l.inCluster()
+		if err == nil {
+			return config, Source{Name: "in-cluster service account"}, nil
+		}
+		skipped = append(skipped, "in-cluster: "+err.Error())
+	} else {
+		skipped = append(skipped, "in-cluster: skipped because a context was requested")
+	}
+
+	var candidates []string
+	if home := l.getenv("HOME"); home != "" {
+		candidates = append(candidates, filepath.Join(home, clientcmd.RecommendedHomeDir, clientcmd.RecommendedFileName))
+	} else {
+		skipped = append(skipped, "$HOME/.kube/config: $HOME not set")
+	}
+	candidates = append(candidates, l.snapPaths...)
+	for _, path := range candidates {
+		if err := readable(path); err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		return loadFiles(path, []string{path}, opts.Context)
+	}
+
+	return nil, Source{}, fmt.Errorf("no Kubernetes configuration found:\n  - %s", strings.Join(skipped, "\n  - "))
+}
+
+// loadFiles loads and merges the kubeconfig files in paths like kubectl does.
+func loadFiles(name string, paths []string, context string) (*rest.Config, Source, error) {
+	rules := &clientcmd.ClientConfigLoadingRules{Precedence: paths}
+	if len(paths) == 1 {
+		rules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: paths[0]}
+	}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: context})
+
+	source := Source{Name: name, Paths: paths, Context: context}
+	raw, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, source, fmt.Errorf("loading kubeconfig from %s: %w", source.Name, err)
+	}
+	if source.Context == "" {
+		source.Context = raw.CurrentContext
+	}
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, source, fmt.Errorf("loading kubeconfig from %s: %w", source, err)
+	}
+	return config, source, nil
+}
+
+// readable returns an error describing why path cannot be read.
+func readable(path string) error {
+	f, err := os.Open(path)
+	switch {
+	case err == nil:
+		return f.Close()
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%s: not found", path)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%s: permission denied, it may only be readable by root", path)
+	default:
+		return err
+	}
+}
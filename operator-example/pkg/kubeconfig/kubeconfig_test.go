@@ -0,0 +1,189 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: one
+  cluster:
+    server: https://one.example.com
+- name: two
+  cluster:
+    server: https://two.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: one
+  context:
+    cluster: one
+    user: admin
+- name: two
+  context:
+    cluster: two
+    user: admin
+current-context: one
+`
+
+func writeKubeconfig(t *testing.T, dir string, parts ...string) string {
+	t.Helper()
+	path := filepath.Join(append([]string{dir}, parts...)...)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	explicit := writeKubeconfig(t, dir, "explicit")
+	fromEnv := writeKubeconfig(t, dir, "env")
+	home := filepath.Join(dir, "home")
+	homeConfig := writeKubeconfig(t, home, ".kube", "config")
+	snap := writeKubeconfig(t, dir, "snap", "admin.conf")
+	missing := filepath.Join(dir, "missing")
+
+	inCluster := func() (*rest.Config, error) { return &rest.Config{Host: "https://in-cluster"}, nil }
+	notInCluster := func() (*rest.Config, error) { return nil, rest.ErrNotInCluster }
+
+	tests := []struct {
+		name      string
+		opts      Options
+		env       map[string]string
+		inCluster func() (*rest.Config, error)
+		snapPaths []string
+		host      string
+		source    string
+		wantErr   string
+	}{
+		{
+			name:   "explicit kubeconfig wins",
+			opts:   Options{Kubeconfig: explicit},
+			env:    map[string]string{"KUBECONFIG": fromEnv, "HOME": home},
+			host:   "https://one.example.com",
+			source: "--kubeconfig",
+		},
+		{
+			name:   "explicit context",
+			opts:   Options{Kubeconfig: explicit, Context: "two"},
+			host:   "https://two.example.com",
+			source: "--kubeconfig",
+		},
+		{
+			name:    "missing explicit kubeconfig is an error",
+			opts:    Options{Kubeconfig: missing},
+			env:     map[string]string{"HOME": home},
+			wantErr: "loading kubeconfig from --kubeconfig",
+		},
+		{
+			name:      "KUBECONFIG list skips missing files",
+			env:       map[string]string{"KUBECONFIG": missing + string(filepath.ListSeparator) + fromEnv, "HOME": home},
+			inCluster: inCluster,
+			host:      "https://one.example.com",
+			source:    "$KUBECONFIG",
+		},
+		{
+			name:      "in-cluster before the home directory",
+			env:       map[string]string{"HOME": home},
+			inCluster: inCluster,
+			host:      "https://in-cluster",
+			source:    "in-cluster service account",
+		},
+		{
+			name:      "context skips in-cluster",
+			opts:      Options{Context: "two"},
+			env:       map[string]string{"HOME": home},
+			inCluster: inCluster,
+			host:      "https://two.example.com",
+			source:    homeConfig,
+		},
+		{
+			name:      "snap kubeconfig",
+			env:       map[string]string{"HOME": filepath.Join(dir, "empty")},
+			snapPaths: []string{missing, snap},
+			host:      "https://one.example.com",
+			source:    snap,
+		},
+		{
+			name:      "nothing found",
+			env:       map[string]string{"HOME": filepath.Join(dir, "empty")},
+			snapPaths: []string{missing},
+			wantErr:   missing + ": not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := loader{
+				getenv:    func(key string) string { return tt.env[key] },
+				inCluster: tt.inCluster,
+				snapPaths: tt.snapPaths,
+			}
+			if l.inCluster == nil {
+				l.inCluster = notInCluster
+			}
+
+			config, source, err := l.load(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if config.Host != tt.host {
+				t.Errorf("host = %q, want %q", config.Host, tt.host)
+			}
+			if source.Name != tt.source {
+				t.Errorf("source = %q, want %q", source.Name, tt.source)
+			}
+		})
+	}
+}
+
+func TestLoadReportsEverySkippedStep(t *testing.T) {
+	l := loader{
+		getenv:    func(string) string { return "" },
+		inCluster: func() (*rest.Config, error) { return nil, errors.New("not running in a pod") },
+	}
+	_, _, err := l.load(Options{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"--kubeconfig: not set", "$KUBECONFIG: not set", "in-cluster: not running in a pod", "$HOME not set"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+//go:build e2e_local
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package local runs the e2e tests without a cluster or network access. The
+// real manager binary runs against the kube-apiserver and etcd binaries of
+// envtest, and utils.FakeKubelet plays the missing controllers and nodes.
+package local
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+	"github.com/liweinan/k8s-example/operator-example/test/utils"
+)
+
+var (
+	testEnv   *envtest.Environment
+	k8sClient client.Client
+	manager   *exec.Cmd
+	cancel    context.CancelFunc
+)
+
+// Run the offline e2e tests using the Ginkgo runner.
+func TestLocalE2E(t *testing.T) {
+	RegisterFailHandler(Fail)
+	fmt.Fprintf(GinkgoWriter, "Starting operator-example local e2e suite\n")
+	RunSpecs(t, "local e2e suite")
+}
+
+var _ = BeforeSuite(func() {
+	projectDir, err := utils.GetProjectDir()
+	Expect(err).NotTo(HaveOccurred())
+	workDir, err := os.MkdirTemp("", "operator-example-e2e-")
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(os.RemoveAll, workDir)
+
+	By("starting a local control plane")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join(projectDir, "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+		// Overridden by KUBEBUILDER_ASSETS, see the test-e2e-local target
+		BinaryAssetsDirectory: filepath.Join(projectDir, "bin", "k8s",
+			fmt.Sprintf("1.29.0-%s-%s", runtime.GOOS, runtime.GOARCH)),
+	}
+	// Without a controller manager nothing creates the default ServiceAccount
+	// that the admission plugin requires for pods
+	testEnv.ControlPlane.GetAPIServer().Configure().Append("disable-admission-plugins", "ServiceAccount")
+	cfg, err := testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	// Registered once the control plane is up, runs after AfterSuite stopped the manager
+	DeferCleanup(func() {
+		By("tearing down the control plane")
+		Expect(testEnv.Stop()).To(Succeed())
+	})
+
+	scheme := k8sruntime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(appsv1beta1.AddToScheme(scheme)).To(Succeed())
+	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme})
+	Expect(err).NotTo(HaveOccurred())
+
+	By("writing a kubeconfig for the manager")
+	user, err := testEnv.AddUser(envtest.User{Name: "operator-example", Groups: []string{"system:masters"}}, nil)
+	Expect(err).NotTo(HaveOccurred())
+	kubeconfig, err := user.KubeConfig()
+	Expect(err).NotTo(HaveOccurred())
+	kubeconfigPath := filepath.Join(workDir, "kubeconfig")
+	Expect(os.WriteFile(kubeconfigPath, kubeconfig, 0o600)).To(Succeed())
+
+	By("building the manager binary")
+	managerPath := filepath.Join(workDir, "manager")
+	_, err = utils.Run(exec.Command("go", "build", "-o", managerPath, "./cmd"))
+	Expect(err).NotTo(HaveOccurred())
+
+	By("starting the manager")
+	probeAddr := freeAddress()
+	manager = exec.Command(managerPath,
+		"--kubeconfig="+kubeconfigPath,
+		"--metrics-bind-address=0",
+		"--health-probe-bind-address="+probeAddr,
+	)
+	// The conversion webhook needs certificates from cert-manager
+	manager.Env = append(os.Environ(), "ENABLE_WEBHOOKS=false")
+	manager.Stdout = GinkgoWriter
+	manager.Stderr = GinkgoWriter
+	Expect(manager.Start()).To(Succeed())
+	Eventually(func() error {
+		resp, err := http.Get("http://" + probeAddr + "/readyz")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("readyz returned %s", resp.Status)
+		}
+		return nil
+	}, time.Minute, time.Second).Should(Succeed())
+
+	By("starting the fake kubelet")
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.Background())
+	go (&utils.FakeKubelet{Client: k8sClient, Interval: 200 * time.Millisecond}).Run(ctx)
+})
+
+var _ = AfterSuite(func() {
+	if cancel != nil {
+		cancel()
+	}
+	if manager != nil && manager.Process != nil {
+		By("stopping the manager")
+		Expect(manager.Process.Signal(os.Interrupt)).To(Succeed())
+		_ = manager.Wait()
+	}
+})
+
+// freeAddress returns a local address with a port nobody listens on
+func freeAddress() string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer l.Close()
+	return l.Addr().String()
+}
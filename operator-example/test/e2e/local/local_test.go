@@ -0,0 +1,155 @@
+//go:build e2e_local
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1beta1 "github.com/liweinan/k8s-example/operator-example/api/v1beta1"
+)
+
+const namespace = "e2e"
+
+var _ = Describe("Application", Ordered, func() {
+	const name = "web"
+
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+
+	getApplication := func(g Gomega) *appsv1beta1.Application {
+		app := &appsv1beta1.Application{}
+		g.Expect(k8sClient.Get(ctx, key, app)).To(Succeed())
+		return app
+	}
+
+	getDeployment := func(g Gomega) *appsv1.Deployment {
+		dep := &appsv1.Deployment{}
+		g.Expect(k8sClient.Get(ctx, key, dep)).To(Succeed())
+		return dep
+	}
+
+	// runningPods returns the images of the running pods of the Application
+	runningPods := func(g Gomega) []string {
+		pods := &corev1.PodList{}
+		g.Expect(k8sClient.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{"app": name})).To(Succeed())
+		var images []string
+		for _, pod := range pods.Items {
+			if pod.DeletionTimestamp == nil && pod.Status.Phase == corev1.PodRunning {
+				images = append(images, pod.Spec.Containers[0].Image)
+			}
+		}
+		return images
+	}
+
+	// expectAvailable waits for the Application to report replicas available pods
+	expectAvailable := func(replicas int32) {
+		Eventually(func(g Gomega) {
+			app := getApplication(g)
+			g.Expect(app.Status.AvailableReplicas).To(Equal(replicas))
+			g.Expect(meta.IsStatusConditionTrue(app.Status.Conditions, appsv1beta1.ConditionAvailable)).To(BeTrue())
+		}, time.Minute, time.Second).Should(Succeed())
+	}
+
+	BeforeAll(func() {
+		By("creating the test namespace")
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).To(Succeed())
+	})
+
+	It("should become Available once its pods are ready", func() {
+		Expect(k8sClient.Create(ctx, &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: appsv1beta1.ApplicationSpec{
+				Image:    "nginx:1.25.3",
+				Replicas: ptr.To[int32](2),
+			},
+		})).To(Succeed())
+
+		expectAvailable(2)
+		Eventually(runningPods, time.Minute, time.Second).Should(HaveLen(2))
+
+		svc := &corev1.Service{}
+		Expect(k8sClient.Get(ctx, key, svc)).To(Succeed())
+		Expect(svc.Spec.Selector).To(Equal(map[string]string{"app": name}))
+		Expect(svc.Spec.Ports).To(HaveLen(1))
+		Expect(svc.Spec.Ports[0].Port).To(Equal(int32(80)))
+	})
+
+	It("should scale", func() {
+		app := getApplication(Default)
+		app.Spec.Replicas = ptr.To[int32](3)
+		Expect(k8sClient.Update(ctx, app)).To(Succeed())
+
+		expectAvailable(3)
+		Eventually(runningPods, time.Minute, time.Second).Should(HaveLen(3))
+	})
+
+	It("should roll out a new image", func() {
+		app := getApplication(Default)
+		app.Spec.Image = "nginx:1.27.0"
+		Expect(k8sClient.Update(ctx, app)).To(Succeed())
+
+		Eventually(runningPods, time.Minute, time.Second).Should(ConsistOf("nginx:1.27.0", "nginx:1.27.0", "nginx:1.27.0"))
+		expectAvailable(3)
+		Expect(getDeployment(Default).Labels).To(HaveKeyWithValue("app.kubernetes.io/version", "1.27.0"))
+	})
+
+	It("should revert changes made to the Deployment behind its back", func() {
+		dep := getDeployment(Default)
+		dep.Spec.Template.Spec.Containers[0].Image = "nginx:hacked"
+		Expect(k8sClient.Update(ctx, dep)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(getDeployment(g).Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27.0"))
+		}, time.Minute, time.Second).Should(Succeed())
+		Eventually(runningPods, time.Minute, time.Second).Should(ConsistOf("nginx:1.27.0", "nginx:1.27.0", "nginx:1.27.0"))
+	})
+
+	It("should refuse to take over objects it does not own", func() {
+		Expect(k8sClient.Create(ctx, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "taken", Namespace: namespace},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "other", Port: 9090}}},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &appsv1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "taken", Namespace: namespace},
+			Spec:       appsv1beta1.ApplicationSpec{Image: "nginx:1.25.3", Replicas: ptr.To[int32](1)},
+		})).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			app := &appsv1beta1.Application{}
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "taken"}, app)).To(Succeed())
+			g.Expect(meta.IsStatusConditionTrue(app.Status.Conditions, appsv1beta1.ConditionConflict)).To(BeTrue())
+		}, time.Minute, time.Second).Should(Succeed())
+
+		svc := &corev1.Service{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "taken"}, svc)).To(Succeed())
+		Expect(svc.OwnerReferences).To(BeEmpty())
+		Expect(svc.Spec.Ports[0].Port).To(Equal(int32(9090)))
+	})
+})
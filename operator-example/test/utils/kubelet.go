@@ -0,0 +1,245 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:golint,revive
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// templateHashAnnotation records the pod template a fake pod was created from
+const templateHashAnnotation = "e2e.example.com/template-hash"
+
+// FakeKubelet stands in for the deployment controller and the kubelet on a
+// control plane without controllers or nodes, such as the one started by
+// envtest. It creates the pods of every Deployment, replaces them when the
+// pod template changes, marks them running and ready and reports the
+// Deployment status. No container is actually run.
+type FakeKubelet struct {
+	Client client.Client
+
+	// Interval between two passes over the Deployments, one second when zero
+	Interval time.Duration
+
+	// nextIP is the last octet of the next pod IP
+	nextIP int
+}
+
+// Run syncs the Deployments until ctx is done.
+func (k *FakeKubelet) Run(ctx context.Context) {
+	interval := k.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Sync(ctx); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(GinkgoWriter, "fake kubelet: %v\n", err)
+			}
+		}
+	}
+}
+
+// Sync brings the pods and the status of every Deployment up to date once.
+func (k *FakeKubelet) Sync(ctx context.Context) error {
+	deployments := &appsv1.DeploymentList{}
+	if err := k.Client.List(ctx, deployments); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		if err := k.syncDeployment(ctx, &deployments.Items[i]); err != nil {
+			return fmt.Errorf("deployment %s/%s: %w", deployments.Items[i].Namespace, deployments.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func (k *FakeKubelet) syncDeployment(ctx context.Context, dep *appsv1.Deployment) error {
+	if dep.DeletionTimestamp != nil {
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	pods := &corev1.PodList{}
+	if err := k.Client.List(ctx, pods, client.InNamespace(dep.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return err
+	}
+
+	hash, err := templateHash(dep.Spec.Template)
+	if err != nil {
+		return err
+	}
+	replicas := int(ptr.Deref(dep.Spec.Replicas, 1))
+
+	// Replace pods of an older template and remove extra ones
+	var current []corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Annotations[templateHashAnnotation] != hash || len(current) >= replicas {
+			if err := k.Client.Delete(ctx, pod, client.GracePeriodSeconds(0)); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			continue
+		}
+		current = append(current, *pod)
+	}
+
+	for len(current) < replicas {
+		pod, err := k.createPod(ctx, dep, hash)
+		if err != nil {
+			return err
+		}
+		current = append(current, *pod)
+	}
+
+	for i := range current {
+		if err := k.startPod(ctx, &current[i]); err != nil {
+			return err
+		}
+	}
+
+	return k.updateStatus(ctx, dep, int32(len(current)))
+}
+
+// createPod creates a pod from the template of dep
+func (k *FakeKubelet) createPod(ctx context.Context, dep *appsv1.Deployment, hash string) (*corev1.Pod, error) {
+	template := dep.Spec.Template.DeepCopy()
+	annotations := map[string]string{templateHashAnnotation: hash}
+	for key, value := range template.Annotations {
+		annotations[key] = value
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: dep.Name + "-",
+			Namespace:    dep.Namespace,
+			Labels:       template.Labels,
+			Annotations:  annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       dep.Name,
+				UID:        dep.UID,
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: template.Spec,
+	}
+	pod.Spec.NodeName = "fake-node"
+	if err := k.Client.Create(ctx, pod); err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
+// startPod reports pod as running and ready
+func (k *FakeKubelet) startPod(ctx context.Context, pod *corev1.Pod) error {
+	if pod.Status.Phase == corev1.PodRunning {
+		return nil
+	}
+	k.nextIP++
+	now := metav1.Now()
+	pod.Status = corev1.PodStatus{
+		Phase:     corev1.PodRunning,
+		HostIP:    "10.0.0.1",
+		PodIP:     fmt.Sprintf("10.244.%d.%d", k.nextIP/250, k.nextIP%250+1),
+		StartTime: &now,
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: now},
+			{Type: corev1.PodInitialized, Status: corev1.ConditionTrue, LastTransitionTime: now},
+			{Type: corev1.ContainersReady, Status: corev1.ConditionTrue, LastTransitionTime: now},
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: now},
+		},
+	}
+	for _, container := range pod.Spec.Containers {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+			Name:    container.Name,
+			Image:   container.Image,
+			ImageID: container.Image,
+			Ready:   true,
+			Started: ptr.To(true),
+			State:   corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: now}},
+		})
+	}
+	return k.Client.Status().Update(ctx, pod)
+}
+
+// updateStatus reports ready pods of the current template on dep
+func (k *FakeKubelet) updateStatus(ctx context.Context, dep *appsv1.Deployment, ready int32) error {
+	condition := appsv1.DeploymentCondition{
+		Type:   appsv1.DeploymentAvailable,
+		Status: corev1.ConditionFalse,
+		Reason: "MinimumReplicasUnavailable",
+	}
+	if ready >= ptr.Deref(dep.Spec.Replicas, 1) {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "MinimumReplicasAvailable"
+	}
+	status := appsv1.DeploymentStatus{
+		ObservedGeneration: dep.Generation,
+		Replicas:           ready,
+		UpdatedReplicas:    ready,
+		ReadyReplicas:      ready,
+		AvailableReplicas:  ready,
+		Conditions:         []appsv1.DeploymentCondition{condition},
+	}
+	// Keep the transition times so that unchanged statuses are not written
+	for _, existing := range dep.Status.Conditions {
+		if existing.Type == condition.Type && existing.Status == condition.Status {
+			status.Conditions[0] = existing
+		}
+	}
+	if equality.Semantic.DeepEqual(dep.Status, status) {
+		return nil
+	}
+	now := metav1.Now()
+	if status.Conditions[0].LastTransitionTime.IsZero() {
+		status.Conditions[0].LastTransitionTime = now
+		status.Conditions[0].LastUpdateTime = now
+	}
+	dep.Status = status
+	return k.Client.Status().Update(ctx, dep)
+}
+
+// templateHash identifies a pod template
+func templateHash(template corev1.PodTemplateSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))[:16], nil
+}
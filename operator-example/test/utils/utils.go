@@ -135,6 +135,9 @@ func GetProjectDir() (string, error) {
 	if err != nil {
 		return wd, err
 	}
-	wd = strings.Replace(wd, "/test/e2e", "", -1)
+	// Tests may run from a subdirectory of test/e2e
+	if i := strings.Index(wd, "/test/e2e"); i >= 0 {
+		wd = wd[:i]
+	}
 	return wd, nil
 }
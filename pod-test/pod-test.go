@@ -13,8 +13,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/liweinan/k8s-example/operator-example/pkg/kubeconfig"
 )
 
 func verifyEnvironment() {
@@ -66,6 +66,7 @@ func verifyEnvironment() {
 		"/etc/kubernetes/admin.conf",
 		"/etc/kubernetes/kubeconfig",
 		"/var/snap/k8s/current/kubeconfig",
+		"/var/snap/microk8s/current/credentials/client.config",
 	}
 
 	fmt.Println("\n=== Checking kubeconfig locations ===")
@@ -93,44 +94,10 @@ func getCurrentDir() string {
 	return dir
 }
 
-func getKubeconfigPath() string {
-	fmt.Println("\n=== Kubeconfig Path Resolution ===")
-
-	// First try KUBECONFIG environment variable
-	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
-		fmt.Printf("Using KUBECONFIG from environment: %s\n", kubeconfig)
-		return kubeconfig
-	}
-	fmt.Println("No KUBECONFIG environment variable set")
-
-	// Try to get config from k8s kubectl
-	fmt.Println("\nTrying to get config from k8s kubectl...")
-	cmd := exec.Command("k8s", "kubectl", "config", "view", "--raw")
-	output, err := cmd.CombinedOutput()
-	if err == nil {
-		// Create a temporary file with the config
-		tmpFile := filepath.Join(os.TempDir(), "kubeconfig")
-		fmt.Printf("Creating temporary kubeconfig at: %s\n", tmpFile)
-		if err := os.WriteFile(tmpFile, output, 0600); err == nil {
-			fmt.Printf("Successfully created temporary kubeconfig\n")
-			return tmpFile
-		} else {
-			fmt.Printf("Failed to create temporary kubeconfig: %v\n", err)
-		}
-	} else {
-		fmt.Printf("Failed to get config from k8s kubectl: %v\n", err)
-	}
-
-	// If all else fails, return the default path
-	defaultPath := filepath.Join(os.Getenv("HOME"), ".kube", "config")
-	fmt.Printf("\nFalling back to default path: %s\n", defaultPath)
-	fmt.Println("===================================")
-	return defaultPath
-}
-
 func main() {
 	// Parse command line flags
-	kubeconfig := flag.String("kubeconfig", getKubeconfigPath(), "Path to kubeconfig file")
+	kubeconfigPath := flag.String("kubeconfig", "", "Path to kubeconfig file (default: auto-detected)")
+	kubeContext := flag.String("context", "", "Kubeconfig context to use instead of the current context")
 	namespace := flag.String("namespace", "default", "Namespace to create the pod in")
 	debug := flag.Bool("debug", false, "Enable debug mode to show environment information")
 	flag.Parse()
@@ -139,16 +106,16 @@ func main() {
 		verifyEnvironment()
 	}
 
-	// Try to get in-cluster config first
-	config, err := rest.InClusterConfig()
+	// Load the client config from --kubeconfig, $KUBECONFIG, the pod service
+	// account, ~/.kube/config or the k8s/MicroK8s snaps, in that order
+	config, source, err := kubeconfig.Load(kubeconfig.Options{
+		Kubeconfig: *kubeconfigPath,
+		Context:    *kubeContext,
+	})
 	if err != nil {
-		log.Printf("Not running in cluster, trying to use kubeconfig: %v", err)
-		// If not in cluster, use kubeconfig
-		config, err = clientcmd.BuildConfigFromFlags("", *kubeconfig)
-		if err != nil {
-			log.Fatalf("Error creating client config: %v\nPlease ensure you have a valid kubeconfig file at %s", err, *kubeconfig)
-		}
+		log.Fatalf("Error creating client config: %v", err)
 	}
+	fmt.Printf("Using Kubernetes configuration from %s\n", source)
 
 	// Create the clientset
 	clientset, err := kubernetes.NewForConfig(config)